@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/accrualmock"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+)
+
+func main() {
+	cfg := accrualmock.DefaultConfig()
+
+	var (
+		runAddr     string
+		rulesPath   string
+		progression string
+	)
+	flag.StringVar(&runAddr, "a", "localhost:8081", "run address")
+	flag.StringVar(&rulesPath, "rules", "", "path to json file with accrual rules")
+	flag.StringVar(&progression, "progression", "PROCESSED:0s", "status progression, e.g. REGISTERED:0s,PROCESSING:2s,PROCESSED:5s")
+	flag.Float64Var(&cfg.DefaultAccrual, "accrual", cfg.DefaultAccrual, "accrual for orders not matched by any rule")
+	flag.Float64Var(&cfg.Chaos.NoContentRate, "rate-204", 0, "share of requests answered with 204")
+	flag.Float64Var(&cfg.Chaos.TooManyRequestsRate, "rate-429", 0, "share of requests answered with 429")
+	flag.Float64Var(&cfg.Chaos.ServerErrorRate, "rate-500", 0, "share of requests answered with 500")
+	flag.DurationVar(&cfg.Chaos.RetryAfter, "retry-after", cfg.Chaos.RetryAfter, "Retry-After value sent with 429")
+	flag.DurationVar(&cfg.Latency.Min, "latency-min", 0, "min injected latency")
+	flag.DurationVar(&cfg.Latency.Max, "latency-max", 0, "max injected latency")
+	flag.Int64Var(&cfg.Seed, "seed", cfg.Seed, "random seed")
+	flag.Parse()
+
+	if err := logger.NewZapLogger("info"); err != nil {
+		log.Fatalf("logger innit failed: %v", err)
+	}
+	defer logger.Log.Sync()
+
+	if rulesPath != "" {
+		rules, err := accrualmock.LoadRules(rulesPath)
+		if err != nil {
+			logger.Log.Sugar().Fatalf("failed to load rules: %v", err)
+		}
+		cfg.Rules = rules
+	}
+
+	stages, err := accrualmock.ParseProgression(progression)
+	if err != nil {
+		logger.Log.Sugar().Fatalf("failed to parse progression: %v", err)
+	}
+	cfg.Progression = stages
+
+	srv := &http.Server{
+		Addr:              runAddr,
+		Handler:           logger.LoggingReqResMiddleware(logger.Log)(accrualmock.NewHandler(cfg)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		logger.Log.Sugar().Infof("starting accrual mock on %s", runAddr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Log.Sugar().Fatal("error occured on accrual mock:", err)
+		}
+	}()
+
+	quitCh := make(chan os.Signal, 1)
+	signal.Notify(quitCh, syscall.SIGINT, syscall.SIGTERM)
+	<-quitCh
+	logger.Log.Sugar().Infoln("accrual mock shutting down")
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		logger.Log.Sugar().Fatalf("error occured on accrual mock while shutting down: %s", err.Error())
+	}
+}
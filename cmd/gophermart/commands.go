@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+)
+
+const (
+	cmdServe     = "serve"
+	cmdReconcile = "reconcile"
+)
+
+// parseCommand takes the subcommand off os.Args so that the remaining flags
+// are parsed by config.ParseCfg, the server is started when none is given.
+func parseCommand() string {
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		return cmdServe
+	}
+
+	command := os.Args[1]
+	os.Args = append(os.Args[:1], os.Args[2:]...)
+
+	switch command {
+	case cmdServe, cmdReconcile:
+		return command
+	default:
+		log.Fatalf("unknown command: %s", command)
+		return ""
+	}
+}
+
+// runReconcile checks balances once and prints the report, the exit code is
+// 2 when discrepancies are left unresolved.
+func runReconcile(ctx context.Context, reconciliation service.Reconciliation, fix bool) int {
+	report, err := reconciliation.Reconcile(ctx, fix)
+	if err != nil {
+		logger.Log.Sugar().Errorf("balance reconciliation failed: %v", err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		logger.Log.Sugar().Errorf("failed to print reconciliation report: %v", err)
+		return 1
+	}
+
+	if report.Unresolved() > 0 {
+		return 2
+	}
+	return 0
+}
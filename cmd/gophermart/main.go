@@ -3,20 +3,28 @@ package main
 import (
 	"context"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/config"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/accrual"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/grpcapi"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/handlers"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/openapi"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/risk"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/utils"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 func main() {
+	command := parseCommand()
 	cfg := config.ParseCfg()
 	if err := logger.NewZapLogger("info"); err != nil {
 		log.Fatalf("logger innit failed: %v", err)
@@ -33,6 +41,16 @@ func main() {
 		logger.Log.Sugar().Fatalf("failed to apply migrations: %s", err.Error())
 	}
 
+	repos := repository.NewRepository(db)
+
+	reconciliation := service.NewReconciliationService(repos.Reconciliation)
+	if command == cmdReconcile {
+		code := runReconcile(context.Background(), reconciliation, cfg.ReconcileFix)
+		logger.Log.Sync()
+		db.Close()
+		os.Exit(code)
+	}
+
 	workerPoolConfig, err := pgxpool.ParseConfig(cfg.DBUri)
 	if err != nil {
 		logger.Log.Sugar().Errorf("failed to init config for worker pool: %v", err)
@@ -45,7 +63,48 @@ func main() {
 	}
 	defer workerPool.Close()
 
-	orderProcessing, err := service.NewOrderProcessingService(workerPool, cfg.AccrualAddr, "order_notifications")
+	var engine *accrual.Engine
+	if cfg.AccrualRules != "" {
+		var rules accrual.RuleSource = repos.AccrualRules
+		if cfg.AccrualRules != config.RulesFromDB {
+			rules = accrual.NewFileRuleSource(cfg.AccrualRules)
+		}
+
+		engine = accrual.NewEngine(accrual.LocalProviderName, rules, repos.AccrualRules)
+		if err := engine.Reload(context.Background()); err != nil {
+			logger.Log.Sugar().Fatalf("failed to load accrual rules: %v", err)
+		}
+		go engine.Watch(context.Background(), cfg.AccrualRulesReload)
+	}
+
+	providers := accrual.NewDefaultRegistry(cfg.AccrualAddr)
+	if cfg.AccrualProviders != "" {
+		registryCfg, err := accrual.LoadRegistryConfig(cfg.AccrualProviders)
+		if err != nil {
+			logger.Log.Sugar().Fatalf("failed to load accrual providers: %v", err)
+		}
+
+		var extra []accrual.Provider
+		if engine != nil {
+			extra = append(extra, engine)
+		}
+		if providers, err = accrual.NewRegistry(registryCfg, extra...); err != nil {
+			logger.Log.Sugar().Fatalf("failed to init accrual providers: %v", err)
+		}
+	} else if engine != nil && cfg.AccrualAddr == "" {
+		if providers, err = accrual.NewRegistry(accrual.RegistryConfig{Default: engine.Name()}, engine); err != nil {
+			logger.Log.Sugar().Fatalf("failed to init accrual providers: %v", err)
+		}
+	} else if engine != nil {
+		logger.Log.Sugar().Warnf("local accrual rules are inactive, every order goes to %s: route orders to the %s provider with -accrual-providers or unset the accrual address",
+			cfg.AccrualAddr, engine.Name())
+	}
+
+	var processingOpts []service.ProcessingOption
+	if cfg.Tiers != "" {
+		processingOpts = append(processingOpts, service.WithTierMultipliers())
+	}
+	orderProcessing, err := service.NewOrderProcessingService(workerPool, providers, "order_notifications", processingOpts...)
 	if err != nil {
 		logger.Log.Sugar().Fatalf("failed to init order processing service: %v", err)
 	}
@@ -53,14 +112,98 @@ func main() {
 
 	orderProcessing.StartProcessing(context.Background(), cfg.Workers)
 
-	repos := repository.NewRepository(db)
-	services := service.NewService(service.Dependencies{
-		Authorization:   service.NewAuthService(repos.Authorization),
-		Order:           service.NewOrderService(repos.Order),
-		Balance:         service.NewBalanceService(repos.Balance),
+	if cfg.ReconcileInterval > 0 {
+		go reconciliation.Run(context.Background(), cfg.ReconcileInterval, cfg.ReconcileFix)
+	}
+
+	idempotency := service.NewIdempotencyService(repos.Idempotency, cfg.IdempotencyTTL)
+	go idempotency.Run(context.Background(), time.Hour)
+
+	if cfg.PointsExpiration > 0 {
+		expiration := service.NewPointsExpirationService(repos.PointLots)
+		go expiration.Run(context.Background(), cfg.PointsExpiration)
+	}
+
+	withdrawalLimits := models.WithdrawalLimits{
+		MinSum:           models.MoneyFromFloat(cfg.WithdrawMin),
+		MaxSum:           models.MoneyFromFloat(cfg.WithdrawMax),
+		DailySum:         models.MoneyFromFloat(cfg.WithdrawDaily),
+		MonthlySum:       models.MoneyFromFloat(cfg.WithdrawMonthly),
+		PasswordCooldown: cfg.WithdrawCooldown,
+	}
+
+	var tiers *service.TiersService
+	if cfg.Tiers != "" {
+		tierDefs, err := service.LoadTiers(cfg.Tiers)
+		if err != nil {
+			logger.Log.Sugar().Fatalf("failed to load loyalty tiers: %v", err)
+		}
+		tiers = service.NewTiersService(repos.Tiers, tierDefs)
+		go tiers.Run(context.Background(), cfg.TiersRecompute)
+	}
+
+	transferLimits := models.TransferLimits{
+		DailySum:   models.MoneyFromFloat(cfg.TransferDailySum),
+		DailyCount: cfg.TransferDailyCount,
+	}
+
+	var riskAssessor service.RiskAssessor
+	var riskService *service.RiskService
+	if cfg.Risk != "" {
+		riskCfg, err := risk.LoadConfig(cfg.Risk)
+		if err != nil {
+			logger.Log.Sugar().Fatalf("failed to load risk config: %v", err)
+		}
+		riskService = service.NewRiskService(repos.Risk, riskCfg)
+		riskAssessor = riskService
+	}
+
+	withdrawalAccrual, err := models.ParseWithdrawalAccrualPolicy(cfg.WithdrawAccrual)
+	if err != nil {
+		logger.Log.Sugar().Fatalf("failed to parse withdrawal order accrual policy: %v", err)
+	}
+
+	holds := service.NewHoldsService(repos.Holds, cfg.HoldTTL, withdrawalLimits, withdrawalAccrual, riskAssessor)
+	go holds.Run(context.Background(), time.Minute)
+
+	referralRewards := models.ReferralRewards{
+		Referrer: models.MoneyFromFloat(cfg.ReferrerReward),
+		Referee:  models.MoneyFromFloat(cfg.RefereeReward),
+	}
+
+	deps := service.Dependencies{
+		Authorization:   service.NewAuthService(repos.Authorization, referralRewards),
+		Order:           service.NewOrderService(repos.Order, riskAssessor),
+		Balance:         service.NewBalanceService(repos.Balance, withdrawalLimits, withdrawalAccrual, riskAssessor),
+		Holds:           holds,
+		Transfers:       service.NewTransfersService(repos.Transfers, transferLimits, withdrawalLimits, riskAssessor),
+		Referrals:       service.NewReferralsService(repos.Referrals),
+		Statements:      service.NewStatementsService(repos.Statements),
+		Campaigns:       service.NewCampaignsService(repos.Campaigns),
 		OrderProcessing: orderProcessing,
-	})
-	handler := handlers.NewHandler(cfg, services)
+		Idempotency:     idempotency,
+	}
+	if engine != nil {
+		deps.AccrualRules = engine
+	}
+	if tiers != nil {
+		deps.Tiers = tiers
+	}
+	if riskService != nil {
+		deps.Risk = riskService
+	}
+	services := service.NewService(deps)
+
+	apiDoc, err := openapi.Load()
+	if err != nil {
+		logger.Log.Sugar().Fatalf("failed to load openapi document: %v", err)
+	}
+	openAPIHandler, err := handlers.NewOpenAPIHandler(apiDoc)
+	if err != nil {
+		logger.Log.Sugar().Fatalf("failed to set up request validation: %v", err)
+	}
+
+	handler := handlers.NewHandler(cfg, services, openAPIHandler)
 	srv := &handlers.Server{}
 
 	go func() {
@@ -70,6 +213,22 @@ func main() {
 		}
 	}()
 
+	if cfg.GRPCAddr != "" {
+		lis, err := net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			logger.Log.Sugar().Fatalf("failed to listen for grpc: %v", err)
+		}
+		grpcSrv := grpcapi.NewGRPCServer(services)
+		defer grpcapi.Shutdown(grpcSrv, 5*time.Second)
+
+		go func() {
+			logger.Sugar.Infof("starting grpc server on %s", cfg.GRPCAddr)
+			if err := grpcSrv.Serve(lis); err != nil {
+				logger.Log.Sugar().Fatal("error occured on grpc server:", err)
+			}
+		}()
+	}
+
 	quitCh := make(chan os.Signal, 1)
 	signal.Notify(quitCh, syscall.SIGINT, syscall.SIGTERM)
 	<-quitCh
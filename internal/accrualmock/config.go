@@ -0,0 +1,138 @@
+package accrualmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	StatusRegistered = "REGISTERED"
+	StatusProcessing = "PROCESSING"
+	StatusProcessed  = "PROCESSED"
+	StatusInvalid    = "INVALID"
+)
+
+// Rule assigns a deterministic outcome to every order whose number starts with Prefix.
+// Accrual is a fixed reward, Percent is applied to the last four digits of the number
+// treated as a purchase amount. Status overrides the final status (e.g. INVALID).
+type Rule struct {
+	Prefix  string  `json:"prefix"`
+	Accrual float64 `json:"accrual,omitempty"`
+	Percent float64 `json:"percent,omitempty"`
+	Status  string  `json:"status,omitempty"`
+}
+
+// Stage is a step of order progression reached After the first request for the order.
+type Stage struct {
+	Status string        `json:"status"`
+	After  time.Duration `json:"after"`
+}
+
+// Chaos describes the share of requests answered with a failure instead of data.
+type Chaos struct {
+	NoContentRate       float64       `json:"no_content_rate"`
+	TooManyRequestsRate float64       `json:"too_many_requests_rate"`
+	ServerErrorRate     float64       `json:"server_error_rate"`
+	RetryAfter          time.Duration `json:"retry_after"`
+}
+
+type Latency struct {
+	Min time.Duration `json:"min"`
+	Max time.Duration `json:"max"`
+}
+
+type Config struct {
+	Rules          []Rule  `json:"rules"`
+	DefaultAccrual float64 `json:"default_accrual"`
+	Progression    []Stage `json:"progression"`
+	Chaos          Chaos   `json:"chaos"`
+	Latency        Latency `json:"latency"`
+	Seed           int64   `json:"seed"`
+}
+
+// DefaultConfig answers every order instantly with PROCESSED and DefaultAccrual points.
+func DefaultConfig() Config {
+	return Config{
+		DefaultAccrual: 500,
+		Progression:    []Stage{{Status: StatusProcessed}},
+		Chaos:          Chaos{RetryAfter: 60 * time.Second},
+		Seed:           1,
+	}
+}
+
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode rules file: %w", err)
+	}
+	return rules, nil
+}
+
+// ParseProgression parses a comma separated list of STATUS:duration pairs,
+// e.g. "REGISTERED:0s,PROCESSING:2s,PROCESSED:5s".
+func ParseProgression(s string) ([]Stage, error) {
+	var stages []Stage
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		status, after, found := strings.Cut(part, ":")
+		stage := Stage{Status: strings.ToUpper(status)}
+		if found {
+			d, err := time.ParseDuration(after)
+			if err != nil {
+				return nil, fmt.Errorf("invalid stage duration %q: %w", part, err)
+			}
+			stage.After = d
+		}
+
+		switch stage.Status {
+		case StatusRegistered, StatusProcessing, StatusProcessed, StatusInvalid:
+		default:
+			return nil, fmt.Errorf("unknown stage status: %s", stage.Status)
+		}
+		stages = append(stages, stage)
+	}
+	return stages, nil
+}
+
+func (c Config) ruleFor(number int64) (Rule, bool) {
+	s := strconv.FormatInt(number, 10)
+	for _, rule := range c.Rules {
+		if strings.HasPrefix(s, rule.Prefix) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (c Config) accrualFor(number int64) (float64, string) {
+	rule, ok := c.ruleFor(number)
+	if !ok {
+		return c.DefaultAccrual, StatusProcessed
+	}
+
+	status := StatusProcessed
+	if rule.Status != "" {
+		status = rule.Status
+	}
+
+	accrual := rule.Accrual
+	if rule.Percent > 0 {
+		amount := float64(number % 10000)
+		accrual += amount * rule.Percent / 100
+	}
+	return math.Round(accrual*100) / 100, status
+}
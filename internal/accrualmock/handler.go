@@ -0,0 +1,148 @@
+// Package accrualmock imitates the external accrual system so that the service
+// can be developed, demoed and tested without the accrual binary.
+package accrualmock
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+type orderResponse struct {
+	Order   string   `json:"order"`
+	Status  string   `json:"status"`
+	Accrual *float64 `json:"accrual,omitempty"`
+}
+
+type Handler struct {
+	cfg       Config
+	router    chi.Router
+	mu        sync.Mutex
+	rnd       *rand.Rand
+	firstSeen map[int64]time.Time
+	now       func() time.Time
+}
+
+func NewHandler(cfg Config) *Handler {
+	if len(cfg.Progression) == 0 {
+		cfg.Progression = []Stage{{Status: StatusProcessed}}
+	}
+
+	h := &Handler{
+		cfg:       cfg,
+		rnd:       rand.New(rand.NewSource(cfg.Seed)),
+		firstSeen: make(map[int64]time.Time),
+		now:       time.Now,
+	}
+
+	r := chi.NewRouter()
+	r.Get("/api/orders/{number}", h.OrderAccrualHandler)
+	h.router = r
+	return h
+}
+
+// NewServer starts an httptest server backed by the mock, its URL can be used
+// as the accrual system address.
+func NewServer(cfg Config) *httptest.Server {
+	return httptest.NewServer(NewHandler(cfg))
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.router.ServeHTTP(w, r)
+}
+
+func (h *Handler) OrderAccrualHandler(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.ParseInt(chi.URLParam(r, "number"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid order number", http.StatusBadRequest)
+		return
+	}
+
+	delay, roll := h.draw()
+	if delay > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+
+	chaos := h.cfg.Chaos
+	switch {
+	case roll < chaos.NoContentRate:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case roll < chaos.NoContentRate+chaos.TooManyRequestsRate:
+		retryAfter := int(chaos.RetryAfter.Seconds())
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		http.Error(w, "No more than 10 requests per minute allowed", http.StatusTooManyRequests)
+		return
+	case roll < chaos.NoContentRate+chaos.TooManyRequestsRate+chaos.ServerErrorRate:
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	status, ok := h.stageFor(number)
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	resp := orderResponse{
+		Order:  strconv.FormatInt(number, 10),
+		Status: status,
+	}
+
+	if status == StatusProcessed {
+		accrual, finalStatus := h.cfg.accrualFor(number)
+		resp.Status = finalStatus
+		if finalStatus == StatusProcessed {
+			resp.Accrual = &accrual
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// draw picks the injected latency and the chaos roll for a single request.
+func (h *Handler) draw() (time.Duration, float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delay := h.cfg.Latency.Min
+	if spread := h.cfg.Latency.Max - h.cfg.Latency.Min; spread > 0 {
+		delay += time.Duration(h.rnd.Int63n(int64(spread)))
+	}
+	return delay, h.rnd.Float64()
+}
+
+// stageFor returns the progression status reached by the order, false means
+// the order is not registered in the accrual system yet.
+func (h *Handler) stageFor(number int64) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	seen, ok := h.firstSeen[number]
+	if !ok {
+		seen = now
+		h.firstSeen[number] = seen
+	}
+	elapsed := now.Sub(seen)
+
+	status := ""
+	for _, stage := range h.cfg.Progression {
+		if elapsed >= stage.After {
+			status = stage.Status
+		}
+	}
+	return status, status != ""
+}
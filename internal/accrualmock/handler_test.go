@@ -0,0 +1,107 @@
+package accrualmock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderAccrualHandler(t *testing.T) {
+	cfg := Config{
+		Rules: []Rule{
+			{Prefix: "1", Accrual: 100},
+			{Prefix: "2", Percent: 10},
+			{Prefix: "3", Status: StatusInvalid},
+			{Prefix: "4", Percent: 2.9},
+		},
+		DefaultAccrual: 5,
+	}
+
+	tests := []struct {
+		name            string
+		number          string
+		expectedStatus  int
+		expectedState   string
+		expectedAccrual float64
+	}{
+		{name: "fixed reward", number: "12345", expectedStatus: http.StatusOK, expectedState: StatusProcessed, expectedAccrual: 100},
+		{name: "percent reward", number: "21000", expectedStatus: http.StatusOK, expectedState: StatusProcessed, expectedAccrual: 100},
+		{name: "percent reward is rounded", number: "40010", expectedStatus: http.StatusOK, expectedState: StatusProcessed, expectedAccrual: 0.29},
+		{name: "invalid order", number: "30000", expectedStatus: http.StatusOK, expectedState: StatusInvalid},
+		{name: "default reward", number: "90000", expectedStatus: http.StatusOK, expectedState: StatusProcessed, expectedAccrual: 5},
+		{name: "bad number", number: "abc", expectedStatus: http.StatusBadRequest},
+	}
+
+	handler := NewHandler(cfg)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/orders/"+tt.number, nil)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var resp orderResponse
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+			assert.Equal(t, tt.number, resp.Order)
+			assert.Equal(t, tt.expectedState, resp.Status)
+			if tt.expectedAccrual > 0 {
+				assert.NotNil(t, resp.Accrual)
+				assert.Equal(t, tt.expectedAccrual, *resp.Accrual)
+			} else {
+				assert.Nil(t, resp.Accrual)
+			}
+		})
+	}
+}
+
+func TestOrderAccrualHandlerProgression(t *testing.T) {
+	handler := NewHandler(Config{
+		DefaultAccrual: 10,
+		Progression: []Stage{
+			{Status: StatusRegistered},
+			{Status: StatusProcessing, After: time.Second},
+			{Status: StatusProcessed, After: 2 * time.Second},
+		},
+	})
+
+	start := time.Now()
+	now := start
+	handler.now = func() time.Time { return now }
+
+	for _, step := range []struct {
+		offset time.Duration
+		status string
+	}{
+		{0, StatusRegistered},
+		{1500 * time.Millisecond, StatusProcessing},
+		{3 * time.Second, StatusProcessed},
+	} {
+		now = start.Add(step.offset)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/orders/79927398713", nil))
+
+		var resp orderResponse
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+		assert.Equal(t, step.status, resp.Status)
+	}
+}
+
+func TestOrderAccrualHandlerChaos(t *testing.T) {
+	server := NewServer(Config{Chaos: Chaos{TooManyRequestsRate: 1, RetryAfter: 30 * time.Second}})
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/orders/79927398713")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+}
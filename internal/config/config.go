@@ -1,37 +1,147 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"log"
+	"net/netip"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env"
 )
 
 type Config struct {
-	RunAddr         string `env:"RUN_ADDRESS"`
-	DBUri           string `env:"DATABASE_URI"`
-	AccrualAddr     string `env:"ACCRUAL_SYSTEM_ADDRESS"`
-	WorkerPoolConns int    `env:"WP_CONNS"`
-	Workers         int    `env:"WORKERS"`
+	RunAddr            string        `env:"RUN_ADDRESS"`
+	GRPCAddr           string        `env:"GRPC_ADDRESS"`
+	DBUri              string        `env:"DATABASE_URI"`
+	AccrualAddr        string        `env:"ACCRUAL_SYSTEM_ADDRESS"`
+	AccrualProviders   string        `env:"ACCRUAL_PROVIDERS_CONFIG"`
+	WorkerPoolConns    int           `env:"WP_CONNS"`
+	Workers            int           `env:"WORKERS"`
+	AccrualRules       string        `env:"ACCRUAL_RULES"`
+	AccrualRulesReload time.Duration `env:"ACCRUAL_RULES_RELOAD"`
+	AdminToken         string        `env:"ADMIN_TOKEN"`
+	ReconcileInterval  time.Duration `env:"RECONCILE_INTERVAL"`
+	ReconcileFix       bool          `env:"RECONCILE_FIX"`
+	IdempotencyTTL     time.Duration `env:"IDEMPOTENCY_TTL"`
+	HoldTTL            time.Duration `env:"HOLD_TTL"`
+	PointsExpiration   time.Duration `env:"POINTS_EXPIRATION_INTERVAL"`
+	TransferDailySum   float64       `env:"TRANSFER_DAILY_LIMIT"`
+	TransferDailyCount int           `env:"TRANSFER_DAILY_COUNT"`
+	Tiers              string        `env:"TIERS_CONFIG"`
+	TiersRecompute     time.Duration `env:"TIERS_RECOMPUTE_INTERVAL"`
+	ReferrerReward     float64       `env:"REFERRER_REWARD"`
+	RefereeReward      float64       `env:"REFEREE_REWARD"`
+	WithdrawMin        float64       `env:"WITHDRAW_MIN"`
+	WithdrawMax        float64       `env:"WITHDRAW_MAX"`
+	WithdrawDaily      float64       `env:"WITHDRAW_DAILY_LIMIT"`
+	WithdrawMonthly    float64       `env:"WITHDRAW_MONTHLY_LIMIT"`
+	WithdrawCooldown   time.Duration `env:"WITHDRAW_PASSWORD_COOLDOWN"`
+	WithdrawAccrual    string        `env:"WITHDRAWAL_ORDER_ACCRUAL"`
+	Risk               string        `env:"RISK_CONFIG"`
+	TrustedProxies     string        `env:"TRUSTED_PROXIES"`
 }
 
+// RulesFromDB as the accrual rules source loads local accrual rules from the database.
+const RulesFromDB = "db"
+
 func ParseCfg() *Config {
 	var cfg Config
 	flag.StringVar(&cfg.RunAddr, "a", "localhost:8080", "run address")
+	flag.StringVar(&cfg.GRPCAddr, "grpc-addr", "", "grpc api address, served without TLS for internal networks, empty disables the grpc api")
 	flag.StringVar(&cfg.DBUri, "d", "", "database uri")
 	flag.StringVar(&cfg.AccrualAddr, "r", "", "accrual system address")
+	flag.StringVar(&cfg.AccrualProviders, "p", "", "path to accrual providers config")
 	flag.IntVar(&cfg.WorkerPoolConns, "c", 12, "max connns for worker pool")
 	flag.IntVar(&cfg.Workers, "w", 1, "total workers")
+	flag.StringVar(&cfg.AccrualRules, "rules", "", "local accrual rules: path to yaml/json file or \"db\"")
+	flag.DurationVar(&cfg.AccrualRulesReload, "rules-reload", 30*time.Second, "local accrual rules reload interval")
+	flag.StringVar(&cfg.AdminToken, "admin-token", "", "token for admin api")
+	flag.DurationVar(&cfg.ReconcileInterval, "reconcile-interval", 0, "balance reconciliation interval, 0 disables it")
+	flag.BoolVar(&cfg.ReconcileFix, "reconcile-fix", false, "fix balance discrepancies found by reconciliation")
+	flag.DurationVar(&cfg.PointsExpiration, "points-expiration-interval", 24*time.Hour, "how often expired points are written off, 0 disables it")
+	flag.Float64Var(&cfg.TransferDailySum, "transfer-daily-limit", 0, "points a user may transfer per day, 0 means no limit")
+	flag.IntVar(&cfg.TransferDailyCount, "transfer-daily-count", 0, "transfers a user may send per day, 0 means no limit")
+	flag.StringVar(&cfg.Tiers, "tiers", "", "path to loyalty tiers config, tiers are disabled without it")
+	flag.DurationVar(&cfg.TiersRecompute, "tiers-recompute", time.Hour, "loyalty tiers recomputation interval")
+	flag.Float64Var(&cfg.ReferrerReward, "referrer-reward", 100, "points credited to the inviting user")
+	flag.Float64Var(&cfg.RefereeReward, "referee-reward", 50, "points credited to the invited user")
+	flag.Float64Var(&cfg.WithdrawMin, "withdraw-min", 0, "minimum sum of a withdrawal, 0 means no minimum")
+	flag.Float64Var(&cfg.WithdrawMax, "withdraw-max", 0, "maximum sum of a withdrawal, 0 means no maximum")
+	flag.Float64Var(&cfg.WithdrawDaily, "withdraw-daily-limit", 0, "points a user may withdraw or transfer per day, 0 means no limit")
+	flag.Float64Var(&cfg.WithdrawMonthly, "withdraw-monthly-limit", 0, "points a user may withdraw or transfer per month, 0 means no limit")
+	flag.DurationVar(&cfg.WithdrawCooldown, "withdraw-password-cooldown", 0, "withdrawals are blocked this long after a password change")
+	flag.StringVar(&cfg.WithdrawAccrual, "withdrawal-order-accrual", "register", "whether orders paid with points are registered for accrual: none or register")
+	flag.StringVar(&cfg.Risk, "risk", "", "path to risk scoring config, risk checks are disabled without it")
+	flag.StringVar(&cfg.TrustedProxies, "trusted-proxies", "", "comma separated addresses or CIDRs of proxies whose X-Forwarded-For is trusted")
+	flag.DurationVar(&cfg.HoldTTL, "hold-ttl", 15*time.Minute, "default expiry of point holds")
+	flag.DurationVar(&cfg.IdempotencyTTL, "idempotency-ttl", 24*time.Hour, "how long responses to idempotent requests are kept")
 	flag.Parse()
 
 	if err := env.Parse(&cfg); err != nil {
 		log.Printf("error occured parsing env variables: %v", err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+
 	log.Println("Running with:")
 	log.Printf("RunAddr: %s", cfg.RunAddr)
+	log.Printf("GRPCAddr: %s", cfg.GRPCAddr)
 	log.Printf("DBUri: %s", cfg.DBUri)
 	log.Printf("AccrualAddr: %s", cfg.AccrualAddr)
 
 	return &cfg
 }
+
+// Validate rejects settings the services cannot run with, e.g. intervals
+// their tickers would panic on.
+func (cfg *Config) Validate() error {
+	if cfg.AccrualRules != "" && cfg.AccrualRulesReload <= 0 {
+		return errors.New("accrual rules reload interval must be positive")
+	}
+	if cfg.Tiers != "" && cfg.TiersRecompute <= 0 {
+		return errors.New("tiers recompute interval must be positive")
+	}
+	if cfg.HoldTTL <= 0 {
+		return errors.New("hold ttl must be positive")
+	}
+	if cfg.IdempotencyTTL <= 0 {
+		return errors.New("idempotency ttl must be positive")
+	}
+	if _, err := cfg.TrustedProxyPrefixes(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TrustedProxyPrefixes parses TrustedProxies, single addresses become
+// prefixes of their full length.
+func (cfg *Config) TrustedProxyPrefixes() ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, raw := range strings.Split(cfg.TrustedProxies, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		if strings.Contains(raw, "/") {
+			prefix, err := netip.ParsePrefix(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", raw, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", raw, err)
+		}
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Config{
+		AccrualRulesReload: 30 * time.Second,
+		TiersRecompute:     time.Hour,
+		HoldTTL:            15 * time.Minute,
+		IdempotencyTTL:     24 * time.Hour,
+	}
+	with := func(change func(*Config)) Config {
+		cfg := valid
+		change(&cfg)
+		return cfg
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "defaults", cfg: valid},
+		{name: "rules without reload", cfg: with(func(c *Config) { c.AccrualRules, c.AccrualRulesReload = RulesFromDB, 0 }), wantErr: true},
+		{name: "rules with reload", cfg: with(func(c *Config) { c.AccrualRules = RulesFromDB })},
+		{name: "tiers without recompute", cfg: with(func(c *Config) { c.Tiers, c.TiersRecompute = "tiers.json", 0 }), wantErr: true},
+		{name: "negative recompute without tiers", cfg: with(func(c *Config) { c.TiersRecompute = -time.Hour })},
+		{name: "zero hold ttl", cfg: with(func(c *Config) { c.HoldTTL = 0 }), wantErr: true},
+		{name: "negative idempotency ttl", cfg: with(func(c *Config) { c.IdempotencyTTL = -time.Hour }), wantErr: true},
+		{name: "trusted proxies", cfg: with(func(c *Config) { c.TrustedProxies = "10.0.0.0/8, 192.168.1.1,::1" })},
+		{name: "invalid trusted proxy", cfg: with(func(c *Config) { c.TrustedProxies = "10.0.0.0/33" }), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
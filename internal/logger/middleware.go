@@ -32,6 +32,12 @@ func (tw *TrackRequestWriter) Header() http.Header {
 	return tw.w.Header()
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer,
+// e.g. to flush streamed responses.
+func (tw *TrackRequestWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
+
 func LoggingReqResMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -28,6 +28,15 @@ func (cw *GzipCompressWriter) Write(p []byte) (int, error) {
 	return cw.gzipWriter.Write(p)
 }
 
+// FlushError sends the compressed data written so far to the client, it is
+// called by http.ResponseController for streamed responses.
+func (cw *GzipCompressWriter) FlushError() error {
+	if err := cw.gzipWriter.Flush(); err != nil {
+		return err
+	}
+	return http.NewResponseController(cw.rw).Flush()
+}
+
 func (cw *GzipCompressWriter) Header() http.Header {
 	if cw.rw.Header().Get("Content-Encoding") != "gzip" {
 		cw.rw.Header().Add("Content-Encoding", "gzip")
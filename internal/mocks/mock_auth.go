@@ -35,6 +35,20 @@ func (m *MockAuthorization) EXPECT() *MockAuthorizationMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockAuthorization) ChangePassword(arg0 context.Context, arg1 int, arg2 models.ChangePasswordRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthorizationMockRecorder) ChangePassword(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthorization)(nil).ChangePassword), arg0, arg1, arg2)
+}
+
 // CreateUser mocks base method.
 func (m *MockAuthorization) CreateUser(arg0 context.Context, arg1 models.User) (int, error) {
 	m.ctrl.T.Helper()
@@ -35,6 +35,21 @@ func (m *MockBalance) EXPECT() *MockBalanceMockRecorder {
 	return m.recorder
 }
 
+// DisplayStatement mocks base method.
+func (m *MockBalance) DisplayStatement(arg0 context.Context, arg1 int, arg2 int64, arg3 int) (models.Statement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisplayStatement", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(models.Statement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisplayStatement indicates an expected call of DisplayStatement.
+func (mr *MockBalanceMockRecorder) DisplayStatement(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisplayStatement", reflect.TypeOf((*MockBalance)(nil).DisplayStatement), arg0, arg1, arg2, arg3)
+}
+
 // DisplayUserBalance mocks base method.
 func (m *MockBalance) DisplayUserBalance(arg0 context.Context, arg1 int) (models.Balance, error) {
 	m.ctrl.T.Helper()
@@ -50,19 +65,33 @@ func (mr *MockBalanceMockRecorder) DisplayUserBalance(arg0, arg1 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisplayUserBalance", reflect.TypeOf((*MockBalance)(nil).DisplayUserBalance), arg0, arg1)
 }
 
-// DisplayWithdrawals mocks base method.
-func (m *MockBalance) DisplayWithdrawals(arg0 context.Context, arg1 int) ([]models.Withdrawal, error) {
+// ReverseWithdrawal mocks base method.
+func (m *MockBalance) ReverseWithdrawal(arg0 context.Context, arg1 int64, arg2 models.ReversalRequest) (models.Withdrawal, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DisplayWithdrawals", arg0, arg1)
-	ret0, _ := ret[0].([]models.Withdrawal)
+	ret := m.ctrl.Call(m, "ReverseWithdrawal", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Withdrawal)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// DisplayWithdrawals indicates an expected call of DisplayWithdrawals.
-func (mr *MockBalanceMockRecorder) DisplayWithdrawals(arg0, arg1 interface{}) *gomock.Call {
+// ReverseWithdrawal indicates an expected call of ReverseWithdrawal.
+func (mr *MockBalanceMockRecorder) ReverseWithdrawal(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseWithdrawal", reflect.TypeOf((*MockBalance)(nil).ReverseWithdrawal), arg0, arg1, arg2)
+}
+
+// StreamWithdrawals mocks base method.
+func (m *MockBalance) StreamWithdrawals(arg0 context.Context, arg1 int, arg2 models.WithdrawalFilter, arg3 func(models.Withdrawal) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamWithdrawals", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamWithdrawals indicates an expected call of StreamWithdrawals.
+func (mr *MockBalanceMockRecorder) StreamWithdrawals(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisplayWithdrawals", reflect.TypeOf((*MockBalance)(nil).DisplayWithdrawals), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamWithdrawals", reflect.TypeOf((*MockBalance)(nil).StreamWithdrawals), arg0, arg1, arg2, arg3)
 }
 
 // WithdrawLoyaltyPoints mocks base method.
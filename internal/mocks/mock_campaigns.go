@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service (interfaces: Campaigns)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCampaigns is a mock of Campaigns interface.
+type MockCampaigns struct {
+	ctrl     *gomock.Controller
+	recorder *MockCampaignsMockRecorder
+}
+
+// MockCampaignsMockRecorder is the mock recorder for MockCampaigns.
+type MockCampaignsMockRecorder struct {
+	mock *MockCampaigns
+}
+
+// NewMockCampaigns creates a new mock instance.
+func NewMockCampaigns(ctrl *gomock.Controller) *MockCampaigns {
+	mock := &MockCampaigns{ctrl: ctrl}
+	mock.recorder = &MockCampaignsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCampaigns) EXPECT() *MockCampaignsMockRecorder {
+	return m.recorder
+}
+
+// CreateCampaign mocks base method.
+func (m *MockCampaigns) CreateCampaign(arg0 context.Context, arg1 models.Campaign) (models.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCampaign", arg0, arg1)
+	ret0, _ := ret[0].(models.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCampaign indicates an expected call of CreateCampaign.
+func (mr *MockCampaignsMockRecorder) CreateCampaign(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCampaign", reflect.TypeOf((*MockCampaigns)(nil).CreateCampaign), arg0, arg1)
+}
+
+// DeleteCampaign mocks base method.
+func (m *MockCampaigns) DeleteCampaign(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCampaign", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCampaign indicates an expected call of DeleteCampaign.
+func (mr *MockCampaignsMockRecorder) DeleteCampaign(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCampaign", reflect.TypeOf((*MockCampaigns)(nil).DeleteCampaign), arg0, arg1)
+}
+
+// GetCampaign mocks base method.
+func (m *MockCampaigns) GetCampaign(arg0 context.Context, arg1 int) (models.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCampaign", arg0, arg1)
+	ret0, _ := ret[0].(models.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCampaign indicates an expected call of GetCampaign.
+func (mr *MockCampaignsMockRecorder) GetCampaign(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCampaign", reflect.TypeOf((*MockCampaigns)(nil).GetCampaign), arg0, arg1)
+}
+
+// ListBonuses mocks base method.
+func (m *MockCampaigns) ListBonuses(arg0 context.Context, arg1 int) ([]models.CampaignBonus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBonuses", arg0, arg1)
+	ret0, _ := ret[0].([]models.CampaignBonus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBonuses indicates an expected call of ListBonuses.
+func (mr *MockCampaignsMockRecorder) ListBonuses(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBonuses", reflect.TypeOf((*MockCampaigns)(nil).ListBonuses), arg0, arg1)
+}
+
+// ListCampaigns mocks base method.
+func (m *MockCampaigns) ListCampaigns(arg0 context.Context) ([]models.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCampaigns", arg0)
+	ret0, _ := ret[0].([]models.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCampaigns indicates an expected call of ListCampaigns.
+func (mr *MockCampaignsMockRecorder) ListCampaigns(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCampaigns", reflect.TypeOf((*MockCampaigns)(nil).ListCampaigns), arg0)
+}
+
+// ReverseBonus mocks base method.
+func (m *MockCampaigns) ReverseBonus(arg0 context.Context, arg1 int64) (models.CampaignBonus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseBonus", arg0, arg1)
+	ret0, _ := ret[0].(models.CampaignBonus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseBonus indicates an expected call of ReverseBonus.
+func (mr *MockCampaignsMockRecorder) ReverseBonus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseBonus", reflect.TypeOf((*MockCampaigns)(nil).ReverseBonus), arg0, arg1)
+}
+
+// UpdateCampaign mocks base method.
+func (m *MockCampaigns) UpdateCampaign(arg0 context.Context, arg1 models.Campaign) (models.Campaign, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCampaign", arg0, arg1)
+	ret0, _ := ret[0].(models.Campaign)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCampaign indicates an expected call of UpdateCampaign.
+func (mr *MockCampaignsMockRecorder) UpdateCampaign(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCampaign", reflect.TypeOf((*MockCampaigns)(nil).UpdateCampaign), arg0, arg1)
+}
@@ -67,16 +67,16 @@ func (mr *MockOrderMockRecorder) CreateOrder(arg0, arg1 interface{}) *gomock.Cal
 }
 
 // ListOrders mocks base method.
-func (m *MockOrder) ListOrders(arg0 context.Context, arg1 int) ([]models.Order, error) {
+func (m *MockOrder) ListOrders(arg0 context.Context, arg1 int, arg2 models.Page) ([]models.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListOrders", arg0, arg1)
+	ret := m.ctrl.Call(m, "ListOrders", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]models.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListOrders indicates an expected call of ListOrders.
-func (mr *MockOrderMockRecorder) ListOrders(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockOrderMockRecorder) ListOrders(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockOrder)(nil).ListOrders), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockOrder)(nil).ListOrders), arg0, arg1, arg2)
 }
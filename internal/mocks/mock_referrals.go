@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service (interfaces: Referrals)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockReferrals is a mock of Referrals interface.
+type MockReferrals struct {
+	ctrl     *gomock.Controller
+	recorder *MockReferralsMockRecorder
+}
+
+// MockReferralsMockRecorder is the mock recorder for MockReferrals.
+type MockReferralsMockRecorder struct {
+	mock *MockReferrals
+}
+
+// NewMockReferrals creates a new mock instance.
+func NewMockReferrals(ctrl *gomock.Controller) *MockReferrals {
+	mock := &MockReferrals{ctrl: ctrl}
+	mock.recorder = &MockReferralsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReferrals) EXPECT() *MockReferralsMockRecorder {
+	return m.recorder
+}
+
+// ListReferrals mocks base method.
+func (m *MockReferrals) ListReferrals(arg0 context.Context, arg1 int) (models.Referrals, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReferrals", arg0, arg1)
+	ret0, _ := ret[0].(models.Referrals)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReferrals indicates an expected call of ListReferrals.
+func (mr *MockReferralsMockRecorder) ListReferrals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReferrals", reflect.TypeOf((*MockReferrals)(nil).ListReferrals), arg0, arg1)
+}
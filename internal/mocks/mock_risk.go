@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service (interfaces: Risk)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRisk is a mock of Risk interface.
+type MockRisk struct {
+	ctrl     *gomock.Controller
+	recorder *MockRiskMockRecorder
+}
+
+// MockRiskMockRecorder is the mock recorder for MockRisk.
+type MockRiskMockRecorder struct {
+	mock *MockRisk
+}
+
+// NewMockRisk creates a new mock instance.
+func NewMockRisk(ctrl *gomock.Controller) *MockRisk {
+	mock := &MockRisk{ctrl: ctrl}
+	mock.recorder = &MockRiskMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRisk) EXPECT() *MockRiskMockRecorder {
+	return m.recorder
+}
+
+// ListReviews mocks base method.
+func (m *MockRisk) ListReviews(arg0 context.Context, arg1 string) ([]models.RiskReview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListReviews", arg0, arg1)
+	ret0, _ := ret[0].([]models.RiskReview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListReviews indicates an expected call of ListReviews.
+func (mr *MockRiskMockRecorder) ListReviews(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListReviews", reflect.TypeOf((*MockRisk)(nil).ListReviews), arg0, arg1)
+}
+
+// ResolveReview mocks base method.
+func (m *MockRisk) ResolveReview(arg0 context.Context, arg1 int64, arg2 models.ReviewResolution) (models.RiskReview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveReview", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.RiskReview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveReview indicates an expected call of ResolveReview.
+func (mr *MockRiskMockRecorder) ResolveReview(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveReview", reflect.TypeOf((*MockRisk)(nil).ResolveReview), arg0, arg1, arg2)
+}
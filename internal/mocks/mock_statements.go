@@ -0,0 +1,52 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service (interfaces: Statements)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	models "github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockStatements is a mock of Statements interface.
+type MockStatements struct {
+	ctrl     *gomock.Controller
+	recorder *MockStatementsMockRecorder
+}
+
+// MockStatementsMockRecorder is the mock recorder for MockStatements.
+type MockStatementsMockRecorder struct {
+	mock *MockStatements
+}
+
+// NewMockStatements creates a new mock instance.
+func NewMockStatements(ctrl *gomock.Controller) *MockStatements {
+	mock := &MockStatements{ctrl: ctrl}
+	mock.recorder = &MockStatementsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStatements) EXPECT() *MockStatementsMockRecorder {
+	return m.recorder
+}
+
+// MonthlyStatement mocks base method.
+func (m *MockStatements) MonthlyStatement(arg0 context.Context, arg1 int, arg2 time.Time) (models.MonthlyStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MonthlyStatement", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.MonthlyStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MonthlyStatement indicates an expected call of MonthlyStatement.
+func (mr *MockStatementsMockRecorder) MonthlyStatement(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MonthlyStatement", reflect.TypeOf((*MockStatements)(nil).MonthlyStatement), arg0, arg1, arg2)
+}
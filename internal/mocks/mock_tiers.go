@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service (interfaces: Tiers)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTiers is a mock of Tiers interface.
+type MockTiers struct {
+	ctrl     *gomock.Controller
+	recorder *MockTiersMockRecorder
+}
+
+// MockTiersMockRecorder is the mock recorder for MockTiers.
+type MockTiersMockRecorder struct {
+	mock *MockTiers
+}
+
+// NewMockTiers creates a new mock instance.
+func NewMockTiers(ctrl *gomock.Controller) *MockTiers {
+	mock := &MockTiers{ctrl: ctrl}
+	mock.recorder = &MockTiersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTiers) EXPECT() *MockTiersMockRecorder {
+	return m.recorder
+}
+
+// UserTier mocks base method.
+func (m *MockTiers) UserTier(arg0 context.Context, arg1 int) (models.TierStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserTier", arg0, arg1)
+	ret0, _ := ret[0].(models.TierStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserTier indicates an expected call of UserTier.
+func (mr *MockTiersMockRecorder) UserTier(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserTier", reflect.TypeOf((*MockTiers)(nil).UserTier), arg0, arg1)
+}
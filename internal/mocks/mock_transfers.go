@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service (interfaces: Transfers)
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTransfers is a mock of Transfers interface.
+type MockTransfers struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransfersMockRecorder
+}
+
+// MockTransfersMockRecorder is the mock recorder for MockTransfers.
+type MockTransfersMockRecorder struct {
+	mock *MockTransfers
+}
+
+// NewMockTransfers creates a new mock instance.
+func NewMockTransfers(ctrl *gomock.Controller) *MockTransfers {
+	mock := &MockTransfers{ctrl: ctrl}
+	mock.recorder = &MockTransfersMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransfers) EXPECT() *MockTransfersMockRecorder {
+	return m.recorder
+}
+
+// ListTransfers mocks base method.
+func (m *MockTransfers) ListTransfers(arg0 context.Context, arg1 int, arg2 models.Page) ([]models.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransfers", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]models.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransfers indicates an expected call of ListTransfers.
+func (mr *MockTransfersMockRecorder) ListTransfers(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockTransfers)(nil).ListTransfers), arg0, arg1, arg2)
+}
+
+// Transfer mocks base method.
+func (m *MockTransfers) Transfer(arg0 context.Context, arg1 int, arg2 models.TransferRequest) (models.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(models.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockTransfersMockRecorder) Transfer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockTransfers)(nil).Transfer), arg0, arg1, arg2)
+}
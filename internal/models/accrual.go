@@ -6,10 +6,10 @@ import (
 )
 
 type AccrualResponse struct {
-	Order      int64   `json:"order,omitempty"`
-	Status     string  `json:"status,omitempty"`
-	Accrual    float64 `json:"accrual,omitempty"`
-	StatusCode int     `json:"-"`
+	Order      int64  `json:"order,omitempty"`
+	Status     string `json:"status,omitempty"`
+	Accrual    Money  `json:"accrual,omitempty"`
+	StatusCode int    `json:"-"`
 }
 
 func (ar *AccrualResponse) UnmarshalJSON(data []byte) (err error) {
@@ -30,3 +30,44 @@ func (ar *AccrualResponse) UnmarshalJSON(data []byte) (err error) {
 	ar.Order = int64(num)
 	return
 }
+
+const (
+	AccrualRulePercent = "percent"
+	AccrualRuleFixed   = "fixed"
+	AccrualRuleCap     = "cap"
+)
+
+// AccrualRule is a rule of the local accrual engine. Percent and fixed rules
+// apply to the whole order or, when Category is set, only to matching goods.
+// Cap limits the points a single rule grants, a rule of type cap limits the
+// total accrual of the order to Value.
+type AccrualRule struct {
+	ID       string  `json:"id" yaml:"id"`
+	Type     string  `json:"type" yaml:"type"`
+	Category string  `json:"category,omitempty" yaml:"category,omitempty"`
+	Value    float64 `json:"value" yaml:"value"`
+	Cap      float64 `json:"cap,omitempty" yaml:"cap,omitempty"`
+}
+
+type OrderItem struct {
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Price       Money  `json:"price"`
+}
+
+type AccrualSimulation struct {
+	Order string      `json:"order,omitempty"`
+	Goods []OrderItem `json:"goods"`
+}
+
+type FiredRule struct {
+	ID      string `json:"id"`
+	Accrual Money  `json:"accrual"`
+	Capped  bool   `json:"capped,omitempty"`
+}
+
+type AccrualSimulationResult struct {
+	Accrual Money       `json:"accrual"`
+	Capped  bool        `json:"capped,omitempty"`
+	Rules   []FiredRule `json:"rules"`
+}
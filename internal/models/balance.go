@@ -2,18 +2,26 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"time"
 )
 
+// Balance.Current includes points reserved by holds, Available is what is
+// left for new withdrawals and holds. ExpiringSoon are the points that expire
+// within the next 30 days.
 type Balance struct {
-	Current   float64 `json:"current"`
-	Withdrawn float64 `json:"withdrawn"`
+	Current      Money       `json:"current"`
+	Available    Money       `json:"available"`
+	Held         Money       `json:"held"`
+	Withdrawn    Money       `json:"withdrawn"`
+	ExpiringSoon Money       `json:"expiring_soon"`
+	Tier         *TierStatus `json:"tier,omitempty"`
 }
 
 type WithdrawRequest struct {
-	Order int64   `json:"order"`
-	Sum   float64 `json:"sum"`
+	Order int64 `json:"order"`
+	Sum   Money `json:"sum"`
 }
 
 func (w *WithdrawRequest) UnmarshalJSON(data []byte) (err error) {
@@ -36,10 +44,30 @@ func (w *WithdrawRequest) UnmarshalJSON(data []byte) (err error) {
 	return
 }
 
+func (w WithdrawRequest) Validate() error {
+	if w.Sum < 0 {
+		return ErrNegativeMoney
+	}
+	if w.Sum == 0 {
+		return fmt.Errorf("%w: sum must be positive", ErrInvalidMoney)
+	}
+	return nil
+}
+
+const (
+	WithdrawalStatusPending   = "PENDING"
+	WithdrawalStatusCompleted = "COMPLETED"
+	WithdrawalStatusReversed  = "REVERSED"
+)
+
 type Withdrawal struct {
-	Order       int64     `json:"order"`
-	Sum         float64   `json:"sum"`
-	ProcessedAt time.Time `json:"processed_at"`
+	ID          int64                `json:"-"`
+	Order       int64                `json:"order"`
+	Sum         Money                `json:"sum"`
+	Status      string               `json:"status"`
+	Reversed    Money                `json:"reversed,omitempty"`
+	Reversals   []WithdrawalReversal `json:"reversals,omitempty"`
+	ProcessedAt time.Time            `json:"processed_at"`
 }
 
 func (t Withdrawal) MarshalJSON() ([]byte, error) {
@@ -55,3 +83,25 @@ func (t Withdrawal) MarshalJSON() ([]byte, error) {
 
 	return json.Marshal(trVal)
 }
+
+// WithdrawalReversal returns points of a withdrawal back to the user,
+// e.g. when the purchase paid with them is refunded.
+type WithdrawalReversal struct {
+	Sum       Money     `json:"sum"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReversalRequest reverses Sum of a withdrawal, zero Sum reverses everything
+// that has not been reversed yet.
+type ReversalRequest struct {
+	Sum    Money  `json:"sum"`
+	Reason string `json:"reason"`
+}
+
+func (r ReversalRequest) Validate() error {
+	if r.Sum < 0 {
+		return ErrNegativeMoney
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	CampaignMultiplier = "multiplier"
+	CampaignFixed      = "fixed"
+
+	BonusStatusCredited = "CREDITED"
+	BonusStatusReversed = "REVERSED"
+)
+
+var ErrInvalidCampaign = errors.New("invalid campaign")
+
+// Campaign credits a bonus for orders processed between StartsAt and EndsAt.
+// Multiplier campaigns add accrual * (Multiplier - 1), so 2 doubles the
+// points, fixed campaigns add Points. Bonuses stop once Budget is spent,
+// zero Budget means no cap. A campaign created without Active is active.
+type Campaign struct {
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Multiplier  float64     `json:"multiplier,omitempty"`
+	Points      Money       `json:"points,omitempty"`
+	Eligibility Eligibility `json:"eligibility"`
+	Budget      Money       `json:"budget,omitempty"`
+	Spent       Money       `json:"spent"`
+	StartsAt    time.Time   `json:"starts_at"`
+	EndsAt      time.Time   `json:"ends_at"`
+	Active      *bool       `json:"active"`
+}
+
+// Eligibility restricts the orders a campaign applies to, empty fields
+// do not restrict anything.
+type Eligibility struct {
+	FirstOrderOnly bool     `json:"first_order_only,omitempty"`
+	MinAccrual     Money    `json:"min_accrual,omitempty"`
+	Tiers          []string `json:"tiers,omitempty"`
+}
+
+// CampaignOrder is what campaigns know about an order that was just processed.
+type CampaignOrder struct {
+	Accrual    Money
+	FirstOrder bool
+	Tier       string
+}
+
+func (c Campaign) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCampaign)
+	}
+
+	switch c.Type {
+	case CampaignMultiplier:
+		if c.Multiplier <= 1 {
+			return fmt.Errorf("%w: multiplier must be greater than 1", ErrInvalidCampaign)
+		}
+		if !MultiplierFits(c.Multiplier) {
+			return fmt.Errorf("%w: multiplier must be below %d with at most 4 decimals", ErrInvalidCampaign, MaxMultiplier)
+		}
+	case CampaignFixed:
+		if c.Points <= 0 {
+			return fmt.Errorf("%w: points must be positive", ErrInvalidCampaign)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidCampaign, c.Type)
+	}
+
+	if !c.EndsAt.After(c.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidCampaign)
+	}
+	if c.Budget < 0 || c.Eligibility.MinAccrual < 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidCampaign, ErrNegativeMoney)
+	}
+	return nil
+}
+
+// Bonus returns the bonus the order earns, budget left is not taken into account.
+func (c Campaign) Bonus(order CampaignOrder) Money {
+	if c.Eligibility.FirstOrderOnly && !order.FirstOrder {
+		return 0
+	}
+	if order.Accrual < c.Eligibility.MinAccrual {
+		return 0
+	}
+	if len(c.Eligibility.Tiers) > 0 && !slices.Contains(c.Eligibility.Tiers, order.Tier) {
+		return 0
+	}
+
+	switch c.Type {
+	case CampaignMultiplier:
+		return order.Accrual.MulPercent((c.Multiplier - 1) * 100)
+	case CampaignFixed:
+		return c.Points
+	}
+	return 0
+}
+
+type CampaignBonus struct {
+	ID         int64      `json:"id"`
+	CampaignID int        `json:"campaign_id"`
+	UserID     int        `json:"user_id"`
+	Order      int64      `json:"order"`
+	Amount     Money      `json:"amount"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReversedAt *time.Time `json:"reversed_at,omitempty"`
+}
+
+func (b CampaignBonus) MarshalJSON() ([]byte, error) {
+	type BonusAlias CampaignBonus
+
+	aliasVal := struct {
+		BonusAlias
+		Order string `json:"order"`
+	}{
+		BonusAlias: BonusAlias(b),
+		Order:      strconv.FormatInt(b.Order, 10),
+	}
+
+	return json.Marshal(aliasVal)
+}
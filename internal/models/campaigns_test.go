@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaignBonus(t *testing.T) {
+	double := Campaign{Type: CampaignMultiplier, Multiplier: 2}
+	firstOrder := Campaign{Type: CampaignFixed, Points: 10000, Eligibility: Eligibility{FirstOrderOnly: true}}
+	goldOnly := Campaign{Type: CampaignMultiplier, Multiplier: 1.5, Eligibility: Eligibility{Tiers: []string{"Gold"}, MinAccrual: 1000}}
+
+	tests := []struct {
+		name     string
+		campaign Campaign
+		order    CampaignOrder
+		expected Money
+	}{
+		{name: "double points", campaign: double, order: CampaignOrder{Accrual: 12345}, expected: 12345},
+		{name: "double points without accrual", campaign: double, order: CampaignOrder{}, expected: 0},
+		{name: "first order bonus", campaign: firstOrder, order: CampaignOrder{FirstOrder: true}, expected: 10000},
+		{name: "not a first order", campaign: firstOrder, order: CampaignOrder{Accrual: 500}, expected: 0},
+		{name: "eligible tier", campaign: goldOnly, order: CampaignOrder{Accrual: 2000, Tier: "Gold"}, expected: 1000},
+		{name: "other tier", campaign: goldOnly, order: CampaignOrder{Accrual: 2000, Tier: "Silver"}, expected: 0},
+		{name: "accrual below minimum", campaign: goldOnly, order: CampaignOrder{Accrual: 999, Tier: "Gold"}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.campaign.Bonus(tt.order))
+		})
+	}
+}
+
+func TestCampaignValidate(t *testing.T) {
+	start := time.Date(2025, 7, 12, 0, 0, 0, 0, time.UTC)
+	valid := Campaign{Name: "weekend", Type: CampaignMultiplier, Multiplier: 2, StartsAt: start, EndsAt: start.Add(48 * time.Hour)}
+	assert.NoError(t, valid.Validate())
+
+	noBoost := valid
+	noBoost.Multiplier = 1
+	assert.ErrorIs(t, noBoost.Validate(), ErrInvalidCampaign)
+
+	tooHigh := valid
+	tooHigh.Multiplier = 100
+	assert.ErrorIs(t, tooHigh.Validate(), ErrInvalidCampaign)
+
+	tooPrecise := valid
+	tooPrecise.Multiplier = 1.00001
+	assert.ErrorIs(t, tooPrecise.Validate(), ErrInvalidCampaign)
+
+	backwards := valid
+	backwards.EndsAt = start.Add(-time.Hour)
+	assert.ErrorIs(t, backwards.Validate(), ErrInvalidCampaign)
+
+	fixed := valid
+	fixed.Type = CampaignFixed
+	assert.ErrorIs(t, fixed.Validate(), ErrInvalidCampaign)
+}
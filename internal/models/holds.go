@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// MaxHoldTTL limits how long a checkout may keep points reserved.
+const MaxHoldTTL = 24 * time.Hour
+
+var (
+	ErrInvalidHold    = errors.New("invalid hold")
+	ErrHoldTTLTooLong = errors.New("hold expiry exceeds the maximum allowed")
+)
+
+const (
+	HoldStatusHeld     = "HELD"
+	HoldStatusCaptured = "CAPTURED"
+	HoldStatusReleased = "RELEASED"
+	HoldStatusExpired  = "EXPIRED"
+)
+
+// Hold reserves points of the user until it is captured into a withdrawal,
+// released or expires. Held points are not available for other withdrawals.
+type Hold struct {
+	ID        int64     `json:"id"`
+	Sum       Money     `json:"sum"`
+	Status    string    `json:"status"`
+	Order     int64     `json:"order,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (h Hold) MarshalJSON() ([]byte, error) {
+	type HoldAlias Hold
+
+	aliasVal := struct {
+		HoldAlias
+		Order string `json:"order,omitempty"`
+	}{
+		HoldAlias: HoldAlias(h),
+	}
+	if h.Order != 0 {
+		aliasVal.Order = strconv.FormatInt(h.Order, 10)
+	}
+
+	return json.Marshal(aliasVal)
+}
+
+// HoldRequest reserves Sum for ExpiresIn seconds, zero ExpiresIn means the
+// default hold ttl.
+type HoldRequest struct {
+	Sum       Money `json:"sum"`
+	ExpiresIn int   `json:"expires_in"`
+}
+
+func (h HoldRequest) Validate() error {
+	if h.Sum < 0 {
+		return ErrNegativeMoney
+	}
+	if h.Sum == 0 {
+		return fmt.Errorf("%w: sum must be positive", ErrInvalidMoney)
+	}
+	if h.ExpiresIn < 0 {
+		return fmt.Errorf("%w: expires_in must not be negative", ErrInvalidHold)
+	}
+	// checked in seconds, converting first could overflow time.Duration
+	if h.ExpiresIn > int(MaxHoldTTL/time.Second) {
+		return ErrHoldTTLTooLong
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	LedgerEntryAccrual    = "accrual"
+	LedgerEntryWithdrawal = "withdrawal"
+	LedgerEntryAdjustment = "adjustment"
+	LedgerEntryReversal   = "reversal"
+	LedgerEntryExpiration = "expiration"
+	LedgerEntryTransfer   = "transfer"
+	LedgerEntryBonus      = "bonus"
+	LedgerEntryReferral   = "referral"
+
+	LedgerEntryBonusReversal = "campaign_bonus_reversal"
+)
+
+// LedgerEntry is the user side of a ledger transaction, Amount is positive for
+// credits and negative for debits.
+type LedgerEntry struct {
+	ID           int64     `json:"id"`
+	Type         string    `json:"type"`
+	Amount       Money     `json:"amount"`
+	BalanceAfter Money     `json:"balance_after"`
+	Order        int64     `json:"order,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (e LedgerEntry) MarshalJSON() ([]byte, error) {
+	type LedgerEntryAlias LedgerEntry
+
+	aliasVal := struct {
+		LedgerEntryAlias
+		Order string `json:"order,omitempty"`
+	}{
+		LedgerEntryAlias: LedgerEntryAlias(e),
+	}
+	if e.Order != 0 {
+		aliasVal.Order = strconv.FormatInt(e.Order, 10)
+	}
+
+	return json.Marshal(aliasVal)
+}
+
+type Statement struct {
+	Entries    []LedgerEntry `json:"entries"`
+	NextCursor int64         `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of loyalty points kept in minor units (hundredths),
+// so that sums stored as NUMERIC(20, 2) never go through float64.
+type Money int64
+
+const moneyScale = 100
+
+var (
+	ErrInvalidMoney   = errors.New("invalid amount")
+	ErrMoneyPrecision = errors.New("amount has more than two decimal places")
+	ErrNegativeMoney  = errors.New("amount must not be negative")
+)
+
+// ParseMoney parses a decimal string like "12", "-3.5" or "700.98".
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidMoney
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" {
+		intPart = "0"
+	}
+
+	if strings.ContainsAny(fracPart, "eE") || strings.ContainsAny(intPart, "eE") {
+		return 0, fmt.Errorf("%w: exponent is not supported: %s", ErrInvalidMoney, s)
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidMoney, s)
+	}
+
+	fracPart = strings.TrimRight(fracPart, "0")
+	if len(fracPart) > 2 {
+		return 0, ErrMoneyPrecision
+	}
+	fracPart += strings.Repeat("0", 2-len(fracPart))
+
+	cents, err := strconv.ParseInt(fracPart, 10, 64)
+	if err != nil || cents < 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidMoney, s)
+	}
+
+	// units*moneyScale + cents must fit in int64, the check is divided
+	// through so that it cannot overflow itself.
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || units < 0 || units > (math.MaxInt64-cents)/moneyScale {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidMoney, s)
+	}
+
+	m := Money(units*moneyScale + cents)
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// isDigits reports whether s consists of ASCII digits only, strconv would
+// also take a sign.
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MoneyFromFloat rounds f to the nearest hundredth, it is meant for
+// configuration values and rates, never for stored sums.
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * moneyScale))
+}
+
+// MulPercent returns percent per cent of m rounded half away from zero.
+func (m Money) MulPercent(percent float64) Money {
+	return Money(math.Round(float64(m) * percent / 100))
+}
+
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/moneyScale, v%moneyScale)
+}
+
+// MarshalJSON encodes m as a JSON number with exactly two decimal places.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts both JSON numbers and strings.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" {
+		return nil
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case []byte:
+		return m.Scan(string(v))
+	case int64:
+		*m = Money(v * moneyScale)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+}
+
+// Value implements driver.Valuer, the amount is sent as a decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
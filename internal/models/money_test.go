@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    Money
+		expectedErr error
+	}{
+		{input: "0", expected: 0},
+		{input: "729.98", expected: 72998},
+		{input: "0.1", expected: 10},
+		{input: "500.50", expected: 50050},
+		{input: "-3.5", expected: -350},
+		{input: "1.230", expected: 123},
+		{input: "10.001", expectedErr: ErrMoneyPrecision},
+		{input: "1e2", expectedErr: ErrInvalidMoney},
+		{input: "abc", expectedErr: ErrInvalidMoney},
+		{input: "1.+5", expectedErr: ErrInvalidMoney},
+		{input: "1.-5", expectedErr: ErrInvalidMoney},
+		{input: "++5", expectedErr: ErrInvalidMoney},
+		{input: "1. 5", expectedErr: ErrInvalidMoney},
+		{input: "", expectedErr: ErrInvalidMoney},
+		{input: "92233720368547758.07", expected: Money(math.MaxInt64)},
+		{input: "92233720368547758.08", expectedErr: ErrInvalidMoney},
+		{input: "92233720368547758.99", expectedErr: ErrInvalidMoney},
+		{input: "92233720368547759", expectedErr: ErrInvalidMoney},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			m, err := ParseMoney(tt.input)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, m)
+		})
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	balance := Balance{Current: 50050, Available: 40050, Held: 10000, Withdrawn: 42}
+	data, err := json.Marshal(balance)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"current": 500.50, "available": 400.50, "held": 100.00, "withdrawn": 0.42, "expiring_soon": 0.00}`, string(data))
+	assert.Contains(t, string(data), "500.50")
+
+	var decoded Balance
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, balance, decoded)
+}
@@ -6,13 +6,34 @@ import (
 	"time"
 )
 
+const (
+	OrderStatusNew        = "NEW"
+	OrderStatusRegistered = "REGISTERED"
+	OrderStatusProcessing = "PROCESSING"
+	OrderStatusInvalid    = "INVALID"
+	OrderStatusProcessed  = "PROCESSED"
+)
+
+// Order is served as is by v1, the breakdown of the accrual is only exposed
+// by v2.
 type Order struct {
-	ID        string    `json:"id,omitempty"`
-	UserID    int       `json:"user_id"`
-	Number    int64     `json:"number"`
-	Status    string    `json:"status,omitempty"`
-	Accrual   float64   `json:"accrual"`
-	CreatedAt time.Time `json:"created_at"`
+	ID           string      `json:"id,omitempty"`
+	UserID       int         `json:"user_id"`
+	Number       int64       `json:"number"`
+	Status       string      `json:"status,omitempty"`
+	StatusReason string      `json:"status_reason,omitempty"`
+	Accrual      Money       `json:"accrual"`
+	BaseAccrual  Money       `json:"-"`
+	Multiplier   float64     `json:"-"`
+	Bonus        Money       `json:"-"`
+	Provider     string      `json:"-"`
+	Goods        []OrderItem `json:"-"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// IsFinal reports whether the order reached a status the accrual system never changes.
+func (o Order) IsFinal() bool {
+	return o.Status == OrderStatusInvalid || o.Status == OrderStatusProcessed
 }
 
 func (o Order) IsEmpty() bool {
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{At: time.Date(2025, 7, 10, 12, 0, 0, 123456000, time.UTC), ID: 42}
+
+	parsed, err := ParseCursor(cursor.String())
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, parsed)
+
+	for _, raw := range []string{"", "nope", "MTIzOmFiYw", "MTIz"} {
+		_, err := ParseCursor(raw)
+		assert.ErrorIs(t, err, ErrInvalidCursor, raw)
+	}
+}
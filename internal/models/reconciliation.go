@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type BalanceDiscrepancy struct {
+	UserID            int   `json:"user_id"`
+	ExpectedCurrent   Money `json:"expected_current"`
+	ActualCurrent     Money `json:"actual_current"`
+	ExpectedWithdrawn Money `json:"expected_withdrawn"`
+	ActualWithdrawn   Money `json:"actual_withdrawn"`
+	Fixed             bool  `json:"fixed"`
+	// Reason tells why a discrepancy was not fixed automatically.
+	Reason string `json:"reason,omitempty"`
+}
+
+type ReconciliationReport struct {
+	CheckedAt     time.Time            `json:"checked_at"`
+	UsersChecked  int                  `json:"users_checked"`
+	Discrepancies []BalanceDiscrepancy `json:"discrepancies"`
+}
+
+// Unresolved returns the number of discrepancies left unfixed.
+func (r ReconciliationReport) Unresolved() int {
+	n := 0
+	for _, d := range r.Discrepancies {
+		if !d.Fixed {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const (
+	ReferralStatusPending  = "PENDING"
+	ReferralStatusRewarded = "REWARDED"
+	ReferralStatusRejected = "REJECTED"
+
+	ReferralReasonSameIP = "registered from the same ip address"
+)
+
+// ReferralRewards are credited to both sides once the first order of the
+// invited user is processed. They are fixed when the invite is used.
+type ReferralRewards struct {
+	Referrer Money
+	Referee  Money
+}
+
+// Referral is an invite as seen by the user who sent it.
+type Referral struct {
+	Login      string     `json:"login"`
+	Status     string     `json:"status"`
+	Reason     string     `json:"reason,omitempty"`
+	Reward     Money      `json:"reward"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RewardedAt *time.Time `json:"rewarded_at,omitempty"`
+}
+
+type Referrals struct {
+	Code      string     `json:"code"`
+	Referrals []Referral `json:"referrals"`
+}
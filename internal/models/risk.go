@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	RiskEventOrderUpload = "order_upload"
+	RiskEventWithdrawal  = "withdrawal"
+	RiskEventHoldCapture = "hold_capture"
+	RiskEventTransfer    = "transfer"
+
+	RiskActionAllow = "allow"
+	RiskActionFlag  = "flag"
+	RiskActionBlock = "block"
+
+	ReviewStatusPending  = "PENDING"
+	ReviewStatusApproved = "APPROVED"
+	ReviewStatusRejected = "REJECTED"
+)
+
+// RiskEvent is an order upload or a movement of points out of the balance to
+// be scored before it is accepted. Transfers carry no order, a whole hold
+// capture no sum.
+type RiskEvent struct {
+	Kind   string `json:"kind"`
+	UserID int    `json:"user_id"`
+	Order  int64  `json:"order,string"`
+	Sum    Money  `json:"sum,omitempty"`
+}
+
+// RiskStats is the account history the risk signals are computed from.
+type RiskStats struct {
+	RecentUploads  int
+	Orders         int
+	InvalidOrders  int
+	AccountAge     time.Duration
+	AccountsSameIP int
+}
+
+// RiskDecision is the outcome of scoring an event, Reasons name the signals
+// that contributed to Score.
+type RiskDecision struct {
+	Score   int      `json:"score"`
+	Action  string   `json:"action"`
+	Reasons []string `json:"reasons"`
+}
+
+// RiskReview is a flagged or blocked event waiting for an admin.
+type RiskReview struct {
+	ID         int64        `json:"id"`
+	Event      RiskEvent    `json:"event"`
+	Decision   RiskDecision `json:"decision"`
+	Status     string       `json:"status"`
+	Note       string       `json:"note,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	ReviewedAt *time.Time   `json:"reviewed_at,omitempty"`
+}
+
+var ErrInvalidResolution = errors.New("resolution status must be APPROVED or REJECTED")
+
+// ReviewResolution records the verdict of an admin. Reviews are advisory: the
+// event was already carried out or rejected when it was scored, resolving the
+// review does not reverse or replay it.
+type ReviewResolution struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+func (r ReviewResolution) Validate() error {
+	if r.Status != ReviewStatusApproved && r.Status != ReviewStatusRejected {
+		return ErrInvalidResolution
+	}
+	return nil
+}
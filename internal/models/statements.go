@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+const statementMonthLayout = "2006-01"
+
+var ErrInvalidStatementMonth = errors.New("invalid statement month, expected yyyy-mm")
+
+// ParseStatementMonth returns the first moment of a yyyy-mm month in UTC.
+func ParseStatementMonth(s string) (time.Time, error) {
+	month, err := time.Parse(statementMonthLayout, s)
+	if err != nil {
+		return time.Time{}, ErrInvalidStatementMonth
+	}
+	return month, nil
+}
+
+// MonthlyStatement sums up the ledger of a user for a calendar month,
+// ClosingBalance = OpeningBalance + Credits - Debits.
+type MonthlyStatement struct {
+	Month          string                `json:"month"`
+	OpeningBalance Money                 `json:"opening_balance"`
+	Credits        Money                 `json:"credits"`
+	Debits         Money                 `json:"debits"`
+	ClosingBalance Money                 `json:"closing_balance"`
+	Totals         []StatementTotal      `json:"totals"`
+	Orders         []StatementOrder      `json:"orders"`
+	Withdrawals    []StatementWithdrawal `json:"withdrawals"`
+	GeneratedAt    time.Time             `json:"generated_at"`
+}
+
+// StatementTotal is the turnover of one ledger entry type within the month.
+type StatementTotal struct {
+	Type    string `json:"type"`
+	Credits Money  `json:"credits"`
+	Debits  Money  `json:"debits"`
+}
+
+type StatementOrder struct {
+	Number      int64     `json:"number,string"`
+	Accrual     Money     `json:"accrual"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+type StatementWithdrawal struct {
+	Order       int64     `json:"order,string"`
+	Sum         Money     `json:"sum"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
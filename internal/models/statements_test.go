@@ -0,0 +1,19 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatementMonth(t *testing.T) {
+	month, err := ParseStatementMonth("2025-06")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), month)
+
+	for _, raw := range []string{"", "2025-6", "2025-13", "2025-06-01", "june"} {
+		_, err := ParseStatementMonth(raw)
+		assert.ErrorIs(t, err, ErrInvalidStatementMonth, raw)
+	}
+}
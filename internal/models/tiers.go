@@ -0,0 +1,44 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// MaxMultiplier bounds tier and campaign multipliers, they are stored as
+// NUMERIC(6, 4).
+const MaxMultiplier = 100
+
+const multiplierScale = 10000
+
+// MultiplierFits reports whether m can be stored as NUMERIC(6, 4) without
+// overflowing or being rounded: below MaxMultiplier with at most four decimals.
+func MultiplierFits(m float64) bool {
+	scaled := m * multiplierScale
+	return m < MaxMultiplier && math.Abs(scaled-math.Round(scaled)) < 1e-6
+}
+
+// Tier is reached once the accruals of the last 12 months reach Threshold,
+// accruals of the tier members are multiplied by Multiplier.
+type Tier struct {
+	Name       string  `json:"name"`
+	Threshold  Money   `json:"threshold"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// UserTier is the tier assigned to a user by the last tier recomputation.
+type UserTier struct {
+	Name           string
+	Multiplier     float64
+	RollingAccrual Money
+	UpdatedAt      time.Time
+}
+
+// TierStatus is the tier of a user and the progress towards the next one.
+type TierStatus struct {
+	Name           string  `json:"name"`
+	Multiplier     float64 `json:"multiplier"`
+	RollingAccrual Money   `json:"rolling_accrual"`
+	NextTier       string  `json:"next_tier,omitempty"`
+	ToNextTier     Money   `json:"to_next_tier,omitempty"`
+}
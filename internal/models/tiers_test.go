@@ -0,0 +1,29 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiplierFits(t *testing.T) {
+	tests := []struct {
+		multiplier float64
+		expected   bool
+	}{
+		{multiplier: 1, expected: true},
+		{multiplier: 1.1, expected: true},
+		{multiplier: 1.2345, expected: true},
+		{multiplier: 99.9999, expected: true},
+		{multiplier: 1.23456, expected: false},
+		{multiplier: 100, expected: false},
+		{multiplier: 1e9, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.multiplier), func(t *testing.T) {
+			assert.Equal(t, tt.expected, MultiplierFits(tt.multiplier))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	TransferIn  = "in"
+	TransferOut = "out"
+
+	maxTransferNoteLen = 255
+)
+
+var ErrInvalidTransfer = errors.New("invalid transfer")
+
+// TransferRequest moves Sum points of the current user to the user with the
+// Recipient login.
+type TransferRequest struct {
+	Recipient string `json:"recipient"`
+	Sum       Money  `json:"sum"`
+	Note      string `json:"note,omitempty"`
+}
+
+func (t TransferRequest) Validate() error {
+	if strings.TrimSpace(t.Recipient) == "" {
+		return fmt.Errorf("%w: recipient is required", ErrInvalidTransfer)
+	}
+	if t.Sum < 0 {
+		return ErrNegativeMoney
+	}
+	if t.Sum == 0 {
+		return fmt.Errorf("%w: sum must be positive", ErrInvalidMoney)
+	}
+	if len(t.Note) > maxTransferNoteLen {
+		return fmt.Errorf("%w: note is longer than %d characters", ErrInvalidTransfer, maxTransferNoteLen)
+	}
+	return nil
+}
+
+// TransferLimits restrict transfers sent by a user per calendar day, zero
+// values mean no limit.
+type TransferLimits struct {
+	DailySum   Money
+	DailyCount int
+}
+
+// Transfer is a transfer as seen by one of its sides, Counterparty is the
+// login of the other user.
+type Transfer struct {
+	ID           int64     `json:"id"`
+	Direction    string    `json:"direction"`
+	Counterparty string    `json:"counterparty"`
+	Sum          Money     `json:"sum"`
+	Note         string    `json:"note,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -1,10 +1,30 @@
 package models
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 type User struct {
 	ID        int       `json:"id"`
 	Login     string    `json:"login"`
 	Password  string    `json:"password"`
 	CreatedAt time.Time `json:"created_at,omitempty"`
+	// ReferralCode is the invite code used on registration, optional.
+	ReferralCode string `json:"referral_code,omitempty"`
+	RegisteredIP string `json:"-"`
+}
+
+var ErrInvalidPasswordChange = errors.New("new password must be set and differ from the old one")
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+func (r ChangePasswordRequest) Validate() error {
+	if r.NewPassword == "" || r.NewPassword == r.OldPassword {
+		return ErrInvalidPasswordChange
+	}
+	return nil
 }
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// WithdrawalLimits are enforced on every withdrawal, hold capture and sent
+// transfer, zero values disable a limit. Daily and monthly sums count calendar
+// days and months of the database clock, reversed points do not count.
+type WithdrawalLimits struct {
+	MinSum           Money
+	MaxSum           Money
+	DailySum         Money
+	MonthlySum       Money
+	PasswordCooldown time.Duration
+}
+
+// LimitError is a withdrawal rejected by a limit, Code tells clients which
+// one so they can explain the rejection.
+type LimitError struct {
+	Code    string
+	Message string
+}
+
+func (e *LimitError) Error() string {
+	return e.Message
+}
+
+var (
+	ErrWithdrawalBelowMinimum = &LimitError{Code: "withdrawal_below_minimum", Message: "withdrawal sum is below the minimum"}
+	ErrWithdrawalAboveMaximum = &LimitError{Code: "withdrawal_above_maximum", Message: "withdrawal sum is above the maximum"}
+	ErrDailyWithdrawalLimit   = &LimitError{Code: "daily_withdrawal_limit", Message: "daily withdrawal limit exceeded"}
+	ErrMonthlyWithdrawalLimit = &LimitError{Code: "monthly_withdrawal_limit", Message: "monthly withdrawal limit exceeded"}
+	ErrPasswordChangeCooldown = &LimitError{Code: "password_change_cooldown", Message: "withdrawals are blocked after a recent password change"}
+)
+
+// CheckSum applies the per-transaction limits.
+func (l WithdrawalLimits) CheckSum(sum Money) error {
+	if l.MinSum > 0 && sum < l.MinSum {
+		return ErrWithdrawalBelowMinimum
+	}
+	if l.MaxSum > 0 && sum > l.MaxSum {
+		return ErrWithdrawalAboveMaximum
+	}
+	return nil
+}
+
+// WithdrawalUsage is what the user has withdrawn so far, used to apply the
+// velocity limits to a new withdrawal.
+type WithdrawalUsage struct {
+	Today             Money
+	ThisMonth         Money
+	PasswordChangedAt *time.Time
+}
+
+func (l WithdrawalLimits) CheckUsage(usage WithdrawalUsage, sum Money, now time.Time) error {
+	if l.PasswordCooldown > 0 && usage.PasswordChangedAt != nil && now.Before(usage.PasswordChangedAt.Add(l.PasswordCooldown)) {
+		return ErrPasswordChangeCooldown
+	}
+	if l.DailySum > 0 && usage.Today+sum > l.DailySum {
+		return ErrDailyWithdrawalLimit
+	}
+	if l.MonthlySum > 0 && usage.ThisMonth+sum > l.MonthlySum {
+		return ErrMonthlyWithdrawalLimit
+	}
+	return nil
+}
+
+// VelocityEnabled reports whether the usage of the user has to be looked up.
+func (l WithdrawalLimits) VelocityEnabled() bool {
+	return l.DailySum > 0 || l.MonthlySum > 0 || l.PasswordCooldown > 0
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithdrawalLimits(t *testing.T) {
+	now := time.Date(2025, 7, 29, 12, 0, 0, 0, time.UTC)
+	changedAt := now.Add(-time.Hour)
+	limits := WithdrawalLimits{
+		MinSum:           1000,
+		MaxSum:           50000,
+		DailySum:         60000,
+		MonthlySum:       200000,
+		PasswordCooldown: 24 * time.Hour,
+	}
+
+	assert.ErrorIs(t, limits.CheckSum(999), ErrWithdrawalBelowMinimum)
+	assert.ErrorIs(t, limits.CheckSum(50001), ErrWithdrawalAboveMaximum)
+	assert.NoError(t, limits.CheckSum(50000))
+	assert.NoError(t, WithdrawalLimits{}.CheckSum(1))
+
+	tests := []struct {
+		name    string
+		usage   WithdrawalUsage
+		sum     Money
+		wantErr error
+	}{
+		{name: "within limits", usage: WithdrawalUsage{Today: 10000, ThisMonth: 10000}, sum: 50000},
+		{name: "daily limit", usage: WithdrawalUsage{Today: 20000, ThisMonth: 20000}, sum: 50000, wantErr: ErrDailyWithdrawalLimit},
+		{name: "monthly limit", usage: WithdrawalUsage{ThisMonth: 180000}, sum: 30000, wantErr: ErrMonthlyWithdrawalLimit},
+		{name: "recent password change", usage: WithdrawalUsage{PasswordChangedAt: &changedAt}, sum: 1000, wantErr: ErrPasswordChangeCooldown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.CheckUsage(tt.usage, tt.sum, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+
+	assert.NoError(t, limits.CheckUsage(WithdrawalUsage{PasswordChangedAt: &changedAt}, 1000, now.Add(24*time.Hour)))
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithdrawalFilter narrows down the withdrawal history. From is inclusive and
+// To is exclusive, zero values disable a bound. Zero Limit means no limit.
+type WithdrawalFilter struct {
+	From   time.Time
+	To     time.Time
+	MinSum Money
+	MaxSum Money
+	After  *Cursor
+	Limit  int
+}
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+func (f WithdrawalFilter) Validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidFilter)
+	}
+	if f.MinSum < 0 || f.MaxSum < 0 {
+		return fmt.Errorf("%w: sums must not be negative", ErrInvalidFilter)
+	}
+	if f.MaxSum > 0 && f.MinSum > f.MaxSum {
+		return fmt.Errorf("%w: min_sum exceeds max_sum", ErrInvalidFilter)
+	}
+	if f.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidFilter)
+	}
+	return nil
+}
+
+var ErrInvalidAccrualPolicy = errors.New("invalid withdrawal accrual policy")
+
+// WithdrawalAccrualPolicy tells whether the order a withdrawal pays for is
+// also registered for accrual. Withdrawal orders are otherwise independent of
+// uploaded orders, one order number can be withdrawn for once.
+type WithdrawalAccrualPolicy string
+
+const (
+	// WithdrawalAccrualNone leaves orders paid with points out of accrual.
+	WithdrawalAccrualNone WithdrawalAccrualPolicy = "none"
+	// WithdrawalAccrualRegister registers the order for accrual along with the
+	// withdrawal, unless it is already known. It is the default, withdrawals
+	// always registered their orders.
+	WithdrawalAccrualRegister WithdrawalAccrualPolicy = "register"
+)
+
+func ParseWithdrawalAccrualPolicy(s string) (WithdrawalAccrualPolicy, error) {
+	switch p := WithdrawalAccrualPolicy(s); p {
+	case WithdrawalAccrualNone, WithdrawalAccrualRegister:
+		return p, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrInvalidAccrualPolicy, s)
+}
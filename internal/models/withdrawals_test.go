@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithdrawalFilterValidate(t *testing.T) {
+	day := time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  WithdrawalFilter
+		wantErr bool
+	}{
+		{name: "empty", filter: WithdrawalFilter{}},
+		{name: "range", filter: WithdrawalFilter{From: day, To: day.AddDate(0, 0, 1), MinSum: 100, MaxSum: 100}},
+		{name: "reversed range", filter: WithdrawalFilter{From: day, To: day}, wantErr: true},
+		{name: "negative sum", filter: WithdrawalFilter{MinSum: -1}, wantErr: true},
+		{name: "min above max", filter: WithdrawalFilter{MinSum: 200, MaxSum: 100}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidFilter)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParseWithdrawalAccrualPolicy(t *testing.T) {
+	for _, raw := range []string{"none", "register"} {
+		policy, err := ParseWithdrawalAccrualPolicy(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, WithdrawalAccrualPolicy(raw), policy)
+	}
+
+	for _, raw := range []string{"", "Register", "always"} {
+		_, err := ParseWithdrawalAccrualPolicy(raw)
+		assert.ErrorIs(t, err, ErrInvalidAccrualPolicy, raw)
+	}
+}
@@ -0,0 +1,209 @@
+package accrual
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+const LocalProviderName = "local"
+
+type RuleSource interface {
+	LoadRules(ctx context.Context) ([]models.AccrualRule, error)
+}
+
+type ItemsSource interface {
+	ListOrderItems(ctx context.Context, orderNumber int64) ([]models.OrderItem, error)
+}
+
+// FileRuleSource reads rules from a YAML or JSON file, picked by extension.
+type FileRuleSource struct {
+	path string
+}
+
+func NewFileRuleSource(path string) *FileRuleSource {
+	return &FileRuleSource{path: path}
+}
+
+func (fs *FileRuleSource) LoadRules(ctx context.Context) ([]models.AccrualRule, error) {
+	data, err := os.ReadFile(fs.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	var rules []models.AccrualRule
+	switch strings.ToLower(filepath.Ext(fs.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &rules)
+	default:
+		err = json.Unmarshal(data, &rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode rules file: %w", err)
+	}
+	return rules, nil
+}
+
+// Engine calculates accruals in process from a set of rules and implements Provider.
+type Engine struct {
+	name  string
+	rules RuleSource
+	items ItemsSource
+	set   atomic.Pointer[[]models.AccrualRule]
+}
+
+func NewEngine(name string, rules RuleSource, items ItemsSource) *Engine {
+	if name == "" {
+		name = LocalProviderName
+	}
+
+	e := &Engine{name: name, rules: rules, items: items}
+	e.set.Store(&[]models.AccrualRule{})
+	return e
+}
+
+func (e *Engine) Name() string {
+	return e.name
+}
+
+// Reload replaces the active rules, the previous ones stay active on error.
+func (e *Engine) Reload(ctx context.Context) error {
+	rules, err := e.rules.LoadRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := ValidateRules(rules); err != nil {
+		return err
+	}
+
+	e.set.Store(&rules)
+	return nil
+}
+
+// Watch reloads the rules every interval until ctx is done.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Reload(ctx); err != nil {
+				logger.Log.Sugar().Errorf("failed to reload accrual rules: %v", err)
+			}
+		}
+	}
+}
+
+func (e *Engine) Rules() []models.AccrualRule {
+	return *e.set.Load()
+}
+
+func (e *Engine) GetAccrual(ctx context.Context, orderNumber int64) (*models.AccrualResponse, error) {
+	items, err := e.items.ListOrderItems(ctx, orderNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	result := Evaluate(e.Rules(), items)
+	return &models.AccrualResponse{
+		Order:   orderNumber,
+		Status:  models.OrderStatusProcessed,
+		Accrual: result.Accrual,
+	}, nil
+}
+
+func (e *Engine) Simulate(ctx context.Context, order models.AccrualSimulation) (models.AccrualSimulationResult, error) {
+	return Evaluate(e.Rules(), order.Goods), nil
+}
+
+func ValidateRules(rules []models.AccrualRule) error {
+	seen := make(map[string]struct{}, len(rules))
+	for _, rule := range rules {
+		if rule.ID == "" {
+			return fmt.Errorf("accrual rule without id")
+		}
+		if _, ok := seen[rule.ID]; ok {
+			return fmt.Errorf("duplicate accrual rule: %s", rule.ID)
+		}
+		seen[rule.ID] = struct{}{}
+
+		switch rule.Type {
+		case models.AccrualRulePercent, models.AccrualRuleFixed, models.AccrualRuleCap:
+		default:
+			return fmt.Errorf("accrual rule %s has unknown type: %s", rule.ID, rule.Type)
+		}
+
+		if rule.Value < 0 || rule.Cap < 0 {
+			return fmt.Errorf("accrual rule %s has negative value", rule.ID)
+		}
+	}
+	return nil
+}
+
+// Evaluate applies rules to the order goods in order and reports every rule that fired.
+func Evaluate(rules []models.AccrualRule, items []models.OrderItem) models.AccrualSimulationResult {
+	result := models.AccrualSimulationResult{Rules: make([]models.FiredRule, 0)}
+	orderCap := models.Money(math.MaxInt64)
+
+	for _, rule := range rules {
+		if rule.Type == models.AccrualRuleCap {
+			orderCap = min(orderCap, models.MoneyFromFloat(rule.Value))
+			continue
+		}
+
+		base, matched := matchItems(rule, items)
+		if !matched {
+			continue
+		}
+
+		fired := models.FiredRule{ID: rule.ID}
+		switch rule.Type {
+		case models.AccrualRulePercent:
+			fired.Accrual = base.MulPercent(rule.Value)
+		case models.AccrualRuleFixed:
+			fired.Accrual = models.MoneyFromFloat(rule.Value)
+		}
+
+		if ruleCap := models.MoneyFromFloat(rule.Cap); ruleCap > 0 && fired.Accrual > ruleCap {
+			fired.Accrual = ruleCap
+			fired.Capped = true
+		}
+
+		result.Accrual += fired.Accrual
+		result.Rules = append(result.Rules, fired)
+	}
+
+	if result.Accrual > orderCap {
+		result.Accrual = orderCap
+		result.Capped = true
+	}
+
+	return result
+}
+
+func matchItems(rule models.AccrualRule, items []models.OrderItem) (models.Money, bool) {
+	var base models.Money
+	matched := rule.Category == ""
+	for _, item := range items {
+		if rule.Category != "" && !strings.EqualFold(item.Category, rule.Category) {
+			continue
+		}
+		matched = true
+		base += item.Price
+	}
+	return base, matched
+}
@@ -0,0 +1,85 @@
+package accrual
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	rules := []models.AccrualRule{
+		{ID: "base", Type: models.AccrualRulePercent, Value: 1},
+		{ID: "bork", Type: models.AccrualRulePercent, Category: "bork", Value: 10, Cap: 500},
+		{ID: "welcome", Type: models.AccrualRuleFixed, Category: "tea", Value: 50},
+	}
+
+	items := []models.OrderItem{
+		{Description: "Чайник Bork", Category: "Bork", Price: models.MoneyFromFloat(7000)},
+		{Description: "Ложка", Category: "kitchen", Price: models.MoneyFromFloat(300)},
+	}
+
+	result := Evaluate(rules, items)
+	assert.Equal(t, models.MoneyFromFloat(573), result.Accrual)
+	assert.Equal(t, []models.FiredRule{
+		{ID: "base", Accrual: models.MoneyFromFloat(73)},
+		{ID: "bork", Accrual: models.MoneyFromFloat(500), Capped: true},
+	}, result.Rules)
+
+	capped := Evaluate(append(rules, models.AccrualRule{ID: "max", Type: models.AccrualRuleCap, Value: 100}), items)
+	assert.Equal(t, models.MoneyFromFloat(100), capped.Accrual)
+	assert.True(t, capped.Capped)
+}
+
+func TestEngineReloadFromYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("- id: base\n  type: percent\n  value: 5\n"), 0o600))
+
+	engine := NewEngine("", NewFileRuleSource(path), nil)
+	assert.NoError(t, engine.Reload(context.Background()))
+	assert.Equal(t, LocalProviderName, engine.Name())
+
+	result, err := engine.Simulate(context.Background(), models.AccrualSimulation{
+		Goods: []models.OrderItem{{Description: "Чайник", Price: models.MoneyFromFloat(1000)}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.MoneyFromFloat(50), result.Accrual)
+
+	assert.NoError(t, os.WriteFile(path, []byte("- id: base\n  type: unknown\n"), 0o600))
+	assert.Error(t, engine.Reload(context.Background()))
+	assert.Len(t, engine.Rules(), 1)
+}
+
+// uploadedGoods stands for the order_items table filled on order upload.
+type uploadedGoods map[int64][]models.OrderItem
+
+func (g uploadedGoods) ListOrderItems(ctx context.Context, orderNumber int64) ([]models.OrderItem, error) {
+	return g[orderNumber], nil
+}
+
+func TestEngineGetAccrualFromUploadedGoods(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	rules := `[{"id": "base", "type": "percent", "value": 1}, {"id": "bork", "type": "percent", "category": "bork", "value": 10}]`
+	assert.NoError(t, os.WriteFile(path, []byte(rules), 0o600))
+
+	goods := uploadedGoods{
+		12345678903: {
+			{Description: "Чайник Bork", Category: "Bork", Price: models.MoneyFromFloat(7000)},
+			{Description: "Ложка", Category: "kitchen", Price: models.MoneyFromFloat(300)},
+		},
+	}
+	engine := NewEngine("", NewFileRuleSource(path), goods)
+	assert.NoError(t, engine.Reload(context.Background()))
+
+	resp, err := engine.GetAccrual(context.Background(), 12345678903)
+	assert.NoError(t, err)
+	assert.Equal(t, models.OrderStatusProcessed, resp.Status)
+	assert.Equal(t, models.MoneyFromFloat(773), resp.Accrual)
+
+	resp, err = engine.GetAccrual(context.Background(), 79927398713)
+	assert.NoError(t, err)
+	assert.Equal(t, models.Money(0), resp.Accrual)
+}
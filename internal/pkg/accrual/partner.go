@@ -0,0 +1,92 @@
+package accrual
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+// PartnerProvider talks to the partner loyalty API:
+// GET {base}/v1/orders/{number}/points answering with partnerResponse.
+type PartnerProvider struct {
+	name    string
+	baseURL string
+	client  *http.Client
+	limiter *limiter
+}
+
+type partnerResponse struct {
+	OrderNumber string       `json:"orderNumber"`
+	State       string       `json:"state"`
+	Points      models.Money `json:"points"`
+}
+
+var partnerStates = map[string]string{
+	"NEW":         models.OrderStatusRegistered,
+	"IN_PROGRESS": models.OrderStatusProcessing,
+	"DONE":        models.OrderStatusProcessed,
+	"REJECTED":    models.OrderStatusInvalid,
+}
+
+func NewPartnerProvider(cfg ProviderConfig) *PartnerProvider {
+	return &PartnerProvider{
+		name:    cfg.Name,
+		baseURL: cfg.BaseURL,
+		client:  newHTTPClient(cfg.Timeout.Duration),
+		limiter: newLimiter(cfg.RateLimit),
+	}
+}
+
+func (p *PartnerProvider) Name() string {
+	return p.name
+}
+
+func (p *PartnerProvider) GetAccrual(ctx context.Context, orderNumber int64) (*models.AccrualResponse, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v1/orders/%d/points", p.baseURL, orderNumber), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrOrderNotRegistered
+	case http.StatusTooManyRequests:
+		return nil, ErrTooManyRequests
+	default:
+		logger.Log.Sugar().Errorf("partner %s returned status %d for order: %d", p.name, resp.StatusCode, orderNumber)
+		return nil, fmt.Errorf("partner %s returned status: %d", p.name, resp.StatusCode)
+	}
+
+	var data partnerResponse
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to decode partner response: %w", err)
+	}
+
+	status, ok := partnerStates[data.State]
+	if !ok {
+		return nil, fmt.Errorf("partner %s returned unknown state: %s", p.name, data.State)
+	}
+
+	return &models.AccrualResponse{
+		Order:      orderNumber,
+		Status:     status,
+		Accrual:    data.Points,
+		StatusCode: resp.StatusCode,
+	}, nil
+}
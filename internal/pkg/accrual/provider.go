@@ -0,0 +1,81 @@
+// Package accrual contains clients of the accrual systems used to calculate
+// loyalty points for orders and the registry routing orders between them.
+package accrual
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+// Provider calculates loyalty points for an order.
+type Provider interface {
+	Name() string
+	GetAccrual(ctx context.Context, orderNumber int64) (*models.AccrualResponse, error)
+}
+
+var (
+	ErrOrderNotRegistered = errors.New("order is not registered in accrual system")
+	ErrTooManyRequests    = errors.New("accrual system rate limit exceeded")
+)
+
+func newHTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			MaxIdleConns:        50,
+			MaxIdleConnsPerHost: 20,
+			IdleConnTimeout:     60 * time.Second,
+		},
+		Timeout: timeout,
+	}
+}
+
+// limiter spaces out requests to a provider so that no more than rps requests
+// per second are sent.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newLimiter(rps float64) *limiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &limiter{interval: time.Duration(float64(time.Second) / rps)}
+}
+
+func (l *limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
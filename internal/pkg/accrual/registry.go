@@ -0,0 +1,166 @@
+package accrual
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+const (
+	AdapterStandard = "standard"
+	AdapterPartner  = "partner"
+
+	DefaultProviderName = "default"
+)
+
+type ProviderConfig struct {
+	Name      string          `json:"name"`
+	Adapter   string          `json:"adapter"`
+	BaseURL   string          `json:"base_url"`
+	RateLimit float64         `json:"rate_limit"`
+	Timeout   models.Duration `json:"timeout"`
+}
+
+// Route sends orders starting with Prefix and lying within [From, To] to
+// Provider, a route needs a prefix, a range or both.
+type Route struct {
+	Prefix   string `json:"prefix,omitempty"`
+	From     int64  `json:"from,omitempty"`
+	To       int64  `json:"to,omitempty"`
+	Provider string `json:"provider"`
+}
+
+func (r Route) matches(number int64) bool {
+	if r.Prefix != "" && !strings.HasPrefix(strconv.FormatInt(number, 10), r.Prefix) {
+		return false
+	}
+	if r.To > 0 && (number < r.From || number > r.To) {
+		return false
+	}
+	return r.Prefix != "" || r.To > 0
+}
+
+func (r Route) validate() error {
+	hasRange := r.From != 0 || r.To != 0
+	if hasRange && (r.From <= 0 || r.To < r.From) {
+		return fmt.Errorf("route to accrual provider %s needs a range with 0 < from <= to", r.Provider)
+	}
+	if !hasRange && r.Prefix == "" {
+		return fmt.Errorf("route to accrual provider %s needs a prefix or a range", r.Provider)
+	}
+	return nil
+}
+
+type RegistryConfig struct {
+	Default   string           `json:"default"`
+	Providers []ProviderConfig `json:"providers"`
+	Routes    []Route          `json:"routes"`
+}
+
+func LoadRegistryConfig(path string) (RegistryConfig, error) {
+	var cfg RegistryConfig
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read accrual providers config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("failed to decode accrual providers config: %w", err)
+	}
+	return cfg, nil
+}
+
+// Registry resolves the provider responsible for an order number. Providers
+// may be registered while orders are being resolved.
+type Registry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	routes    []Route
+	fallback  Provider
+}
+
+// NewRegistry builds adapters listed in cfg, routes may also refer to the
+// in-process providers passed in extra, e.g. the local accrual engine.
+func NewRegistry(cfg RegistryConfig, extra ...Provider) (*Registry, error) {
+	r := &Registry{providers: make(map[string]Provider, len(cfg.Providers)+len(extra))}
+	for _, p := range extra {
+		r.providers[p.Name()] = p
+	}
+
+	for _, pc := range cfg.Providers {
+		if _, ok := r.providers[pc.Name]; ok {
+			return nil, fmt.Errorf("duplicate accrual provider: %s", pc.Name)
+		}
+
+		switch pc.Adapter {
+		case AdapterStandard, "":
+			r.providers[pc.Name] = NewStandardProvider(pc)
+		case AdapterPartner:
+			r.providers[pc.Name] = NewPartnerProvider(pc)
+		default:
+			return nil, fmt.Errorf("unknown adapter %q for accrual provider %s", pc.Adapter, pc.Name)
+		}
+	}
+
+	for _, route := range cfg.Routes {
+		if err := route.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := r.providers[route.Provider]; !ok {
+			return nil, fmt.Errorf("route refers to unknown accrual provider: %s", route.Provider)
+		}
+	}
+	r.routes = cfg.Routes
+
+	if cfg.Default != "" {
+		fallback, ok := r.providers[cfg.Default]
+		if !ok {
+			return nil, fmt.Errorf("unknown default accrual provider: %s", cfg.Default)
+		}
+		r.fallback = fallback
+	}
+
+	return r, nil
+}
+
+// NewDefaultRegistry routes every order to the standard accrual system at addr.
+func NewDefaultRegistry(addr string) *Registry {
+	provider := NewStandardProvider(ProviderConfig{Name: DefaultProviderName, BaseURL: addr})
+	return &Registry{
+		providers: map[string]Provider{DefaultProviderName: provider},
+		fallback:  provider,
+	}
+}
+
+// Register adds a provider, replacing the one with the same name.
+func (r *Registry) Register(p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.providers[p.Name()] = p
+	if r.fallback != nil && r.fallback.Name() == p.Name() {
+		r.fallback = p
+	}
+}
+
+func (r *Registry) Resolve(number int64) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, route := range r.routes {
+		if route.matches(number) {
+			return r.providers[route.Provider], nil
+		}
+	}
+
+	if r.fallback == nil {
+		return nil, fmt.Errorf("no accrual provider for order: %d", number)
+	}
+	return r.fallback, nil
+}
@@ -0,0 +1,119 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/accrualmock"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryResolve(t *testing.T) {
+	standard := accrualmock.NewServer(accrualmock.Config{DefaultAccrual: 500})
+	defer standard.Close()
+
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/orders/4561261212345467/points" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"orderNumber": "4561261212345467", "state": "DONE", "points": 42.5}`))
+	}))
+	defer partner.Close()
+
+	registry, err := NewRegistry(RegistryConfig{
+		Default: "main",
+		Providers: []ProviderConfig{
+			{Name: "main", Adapter: AdapterStandard, BaseURL: standard.URL},
+			{Name: "partner", Adapter: AdapterPartner, BaseURL: partner.URL, RateLimit: 100},
+		},
+		Routes: []Route{
+			{Prefix: "456", Provider: "partner"},
+			{From: 1000, To: 1999, Provider: "partner"},
+		},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		number           int64
+		expectedProvider string
+		expectedAccrual  models.Money
+		expectedErr      error
+	}{
+		{name: "prefix route", number: 4561261212345467, expectedProvider: "partner", expectedAccrual: models.MoneyFromFloat(42.5)},
+		{name: "range route", number: 1230, expectedProvider: "partner", expectedErr: ErrOrderNotRegistered},
+		{name: "default provider", number: 79927398713, expectedProvider: "main", expectedAccrual: models.MoneyFromFloat(500)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := registry.Resolve(tt.number)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedProvider, provider.Name())
+
+			resp, err := provider.GetAccrual(context.Background(), tt.number)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, models.OrderStatusProcessed, resp.Status)
+			assert.Equal(t, tt.expectedAccrual, resp.Accrual)
+		})
+	}
+}
+
+func TestNewRegistryUnknownProvider(t *testing.T) {
+	_, err := NewRegistry(RegistryConfig{
+		Providers: []ProviderConfig{{Name: "main", BaseURL: "http://localhost"}},
+		Routes:    []Route{{Prefix: "1", Provider: "missing"}},
+	})
+	assert.Error(t, err)
+}
+
+func TestNewRegistryInvalidRoute(t *testing.T) {
+	tests := []struct {
+		name  string
+		route Route
+	}{
+		{name: "neither prefix nor range", route: Route{Provider: "main"}},
+		{name: "from without to", route: Route{From: 1000, Provider: "main"}},
+		{name: "from without to next to a prefix", route: Route{Prefix: "1", From: 1000, Provider: "main"}},
+		{name: "to below from", route: Route{From: 2000, To: 1000, Provider: "main"}},
+		{name: "to without from", route: Route{To: 1000, Provider: "main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry(RegistryConfig{
+				Providers: []ProviderConfig{{Name: "main", BaseURL: "http://localhost"}},
+				Routes:    []Route{tt.route},
+			})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRegistryRegisterWhileResolving(t *testing.T) {
+	registry := NewDefaultRegistry("http://localhost")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			registry.Register(NewStandardProvider(ProviderConfig{Name: DefaultProviderName, BaseURL: "http://localhost"}))
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		provider, err := registry.Resolve(79927398713)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultProviderName, provider.Name())
+	}
+	<-done
+}
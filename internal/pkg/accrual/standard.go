@@ -0,0 +1,76 @@
+package accrual
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+// StandardProvider talks to the accrual system shipped with the service:
+// GET {base}/api/orders/{number} answering with models.AccrualResponse.
+type StandardProvider struct {
+	name    string
+	baseURL string
+	client  *http.Client
+	limiter *limiter
+}
+
+func NewStandardProvider(cfg ProviderConfig) *StandardProvider {
+	return &StandardProvider{
+		name:    cfg.Name,
+		baseURL: cfg.BaseURL,
+		client:  newHTTPClient(cfg.Timeout.Duration),
+		limiter: newLimiter(cfg.RateLimit),
+	}
+}
+
+func (p *StandardProvider) Name() string {
+	return p.name
+}
+
+func (p *StandardProvider) GetAccrual(ctx context.Context, orderNumber int64) (*models.AccrualResponse, error) {
+	if err := p.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/orders/%d", p.baseURL, orderNumber), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent:
+		logger.Log.Sugar().Infof("accrual service returned 204 No Content for order: %d", orderNumber)
+		return nil, ErrOrderNotRegistered
+	case http.StatusTooManyRequests:
+		return nil, ErrTooManyRequests
+	default:
+		logger.Log.Sugar().Errorf("accrual service returned status %d for order: %d", resp.StatusCode, orderNumber)
+		return nil, fmt.Errorf("accrual service returned status: %d", resp.StatusCode)
+	}
+
+	if resp.ContentLength == 0 {
+		logger.Log.Sugar().Warnf("accrual service returned empty body for order: %d", orderNumber)
+		return nil, fmt.Errorf("accrual response body is empty")
+	}
+
+	var accrualData models.AccrualResponse
+	if err := json.NewDecoder(resp.Body).Decode(&accrualData); err != nil {
+		logger.Log.Sugar().Errorf("Failed to decode accrual json data: %v", err)
+		return nil, err
+	}
+
+	accrualData.StatusCode = resp.StatusCode
+	return &accrualData, nil
+}
@@ -0,0 +1,86 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/grpcapi/gophermartpb"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type ctxKey string
+
+const userIDKey ctxKey = "userID"
+
+// publicMethods are called without a token.
+var publicMethods = map[string]bool{
+	gophermartpb.Gophermart_Register_FullMethodName: true,
+	gophermartpb.Gophermart_Login_FullMethodName:    true,
+}
+
+func userIDFromContext(ctx context.Context) int {
+	id, _ := ctx.Value(userIDKey).(int)
+	return id
+}
+
+// authenticate puts the id of the user the bearer token in the authorization
+// metadata belongs to into the context.
+func authenticate(ctx context.Context, auth service.Authorization) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	userID, err := auth.ParseToken(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return context.WithValue(ctx, userIDKey, userID), nil
+}
+
+func AuthUnaryInterceptor(auth service.Authorization) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		ctx, err := authenticate(ctx, auth)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func AuthStreamInterceptor(auth service.Authorization) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if publicMethods[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		ctx, err := authenticate(ss.Context(), auth)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,80 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type codeMapping struct {
+	err  error
+	code codes.Code
+}
+
+// codeMappings translate sentinel errors of the service and repository layers
+// into status codes, the first matching entry wins.
+var codeMappings = []codeMapping{
+	{repository.ErrUserExists, codes.AlreadyExists},
+	{repository.ErrInvalidReferralCode, codes.InvalidArgument},
+
+	{models.ErrNegativeMoney, codes.InvalidArgument},
+	{models.ErrMoneyPrecision, codes.InvalidArgument},
+	{models.ErrInvalidMoney, codes.InvalidArgument},
+
+	{repository.ErrAlreadyExists, codes.AlreadyExists},
+	{service.ErrRiskBlocked, codes.PermissionDenied},
+	{repository.ErrInsufficientBalance, codes.FailedPrecondition},
+	{repository.ErrWithdrawalExists, codes.AlreadyExists},
+	{models.ErrInvalidFilter, codes.InvalidArgument},
+	{models.ErrInvalidCursor, codes.InvalidArgument},
+}
+
+// toStatus turns err into a status error. Mapped errors are sent with the
+// text of their sentinel, whatever context they were wrapped in stays on the
+// server. Unknown errors become internal errors, their details are logged and
+// not sent to the client.
+func toStatus(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var limitErr *models.LimitError
+	if errors.As(err, &limitErr) {
+		return status.Error(limitErrorCode(limitErr), limitErr.Message)
+	}
+
+	for _, m := range codeMappings {
+		if errors.Is(err, m.err) {
+			return status.Error(m.code, m.err.Error())
+		}
+	}
+
+	var svcErr *service.OrderServiceError
+	if errors.As(err, &svcErr) && svcErr.RespStatusCode < http.StatusInternalServerError {
+		return status.Error(codes.InvalidArgument, svcErr.Error())
+	}
+
+	method, _ := grpc.Method(ctx)
+	logger.Log.Sugar().Errorw("rpc failed", "method", method, "error", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// limitErrorCode tells the rejections apart like the http api does: velocity
+// caps are exhausted resources, amount limits invalid arguments.
+func limitErrorCode(err *models.LimitError) codes.Code {
+	switch err {
+	case models.ErrDailyWithdrawalLimit, models.ErrMonthlyWithdrawalLimit:
+		return codes.ResourceExhausted
+	case models.ErrPasswordChangeCooldown:
+		return codes.PermissionDenied
+	}
+	return codes.InvalidArgument
+}
@@ -0,0 +1,308 @@
+// Package grpcapi serves the gophermart api over gRPC for internal services.
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative gophermartpb/gophermart.proto
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/grpcapi/gophermartpb"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize      = 50
+	maxPageSize          = 500
+	defaultWatchInterval = 2 * time.Second
+	// watchWindow is how many of the newest orders of the user WatchOrders
+	// follows, older orders are left out.
+	watchWindow = 100
+)
+
+type Server struct {
+	gophermartpb.UnimplementedGophermartServer
+	services      *service.Service
+	watchInterval time.Duration
+}
+
+type ServerOption func(*Server)
+
+// WithWatchInterval sets how often WatchOrders looks for order changes,
+// non-positive intervals keep the default.
+func WithWatchInterval(d time.Duration) ServerOption {
+	return func(s *Server) {
+		if d > 0 {
+			s.watchInterval = d
+		}
+	}
+}
+
+// NewGRPCServer returns a grpc server serving the gophermart api on top of
+// services, calls are authenticated with the tokens of the http api.
+func NewGRPCServer(services *service.Service, opts ...ServerOption) *grpc.Server {
+	s := &Server{services: services, watchInterval: defaultWatchInterval}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(AuthUnaryInterceptor(services.Authorization)),
+		grpc.ChainStreamInterceptor(AuthStreamInterceptor(services.Authorization)),
+	)
+	gophermartpb.RegisterGophermartServer(srv, s)
+	return srv
+}
+
+func (s *Server) Register(ctx context.Context, req *gophermartpb.RegisterRequest) (*gophermartpb.AuthResponse, error) {
+	if req.GetLogin() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "login and password are required")
+	}
+
+	user := models.User{
+		Login:        req.GetLogin(),
+		Password:     req.GetPassword(),
+		ReferralCode: req.GetReferralCode(),
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			user.RegisteredIP = host
+		}
+	}
+
+	id, err := s.services.Authorization.CreateUser(ctx, user)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	token, err := s.services.Authorization.GenerateToken(ctx, user.Login, user.Password)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &gophermartpb.AuthResponse{UserId: int64(id), Token: token}, nil
+}
+
+func (s *Server) Login(ctx context.Context, req *gophermartpb.LoginRequest) (*gophermartpb.AuthResponse, error) {
+	token, err := s.services.Authorization.GenerateToken(ctx, req.GetLogin(), req.GetPassword())
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid login/password")
+	}
+	return &gophermartpb.AuthResponse{Token: token}, nil
+}
+
+func (s *Server) UploadOrder(ctx context.Context, req *gophermartpb.UploadOrderRequest) (*gophermartpb.UploadOrderResponse, error) {
+	num, err := parseOrderNumber(req.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	order := models.Order{
+		UserID: userIDFromContext(ctx),
+		Number: num,
+		Status: models.OrderStatusNew,
+	}
+	respInfo, err := s.services.Order.CreateOrder(ctx, order)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &gophermartpb.UploadOrderResponse{Created: respInfo.RespStatusCode == http.StatusAccepted}, nil
+}
+
+func (s *Server) ListOrders(ctx context.Context, req *gophermartpb.ListOrdersRequest) (*gophermartpb.ListOrdersResponse, error) {
+	page, err := parsePage(req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	// one extra row tells whether there is a next page
+	limit := page.Limit
+	page.Limit++
+	orders, err := s.services.Order.ListOrders(ctx, userIDFromContext(ctx), page)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &gophermartpb.ListOrdersResponse{}
+	if len(orders) > limit {
+		orders = orders[:limit]
+		last := orders[limit-1]
+		resp.NextPageToken = models.Cursor{At: last.CreatedAt, ID: last.Number}.String()
+	}
+	for _, o := range orders {
+		resp.Orders = append(resp.Orders, orderToPB(o))
+	}
+	return resp, nil
+}
+
+// WatchOrders polls the newest watchWindow orders of the user every
+// watchInterval and sends the ones not seen in their current status yet,
+// oldest first.
+func (s *Server) WatchOrders(_ *gophermartpb.WatchOrdersRequest, stream gophermartpb.Gophermart_WatchOrdersServer) error {
+	ctx := stream.Context()
+	userID := userIDFromContext(ctx)
+
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	var seen map[int64]string
+	for {
+		orders, err := s.services.Order.ListOrders(ctx, userID, models.Page{Limit: watchWindow})
+		if err != nil {
+			return toStatus(ctx, err)
+		}
+
+		// only orders still in the window are remembered
+		current := make(map[int64]string, len(orders))
+		for i := len(orders) - 1; i >= 0; i-- {
+			o := orders[i]
+			current[o.Number] = o.Status
+			if last, ok := seen[o.Number]; ok && last == o.Status {
+				continue
+			}
+			if err := stream.Send(orderToPB(o)); err != nil {
+				return err
+			}
+		}
+		seen = current
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) GetBalance(ctx context.Context, _ *gophermartpb.GetBalanceRequest) (*gophermartpb.Balance, error) {
+	balance, err := s.services.Balance.DisplayUserBalance(ctx, userIDFromContext(ctx))
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &gophermartpb.Balance{
+		Current:      balance.Current.String(),
+		Available:    balance.Available.String(),
+		Held:         balance.Held.String(),
+		Withdrawn:    balance.Withdrawn.String(),
+		ExpiringSoon: balance.ExpiringSoon.String(),
+	}, nil
+}
+
+func (s *Server) Withdraw(ctx context.Context, req *gophermartpb.WithdrawRequest) (*gophermartpb.WithdrawResponse, error) {
+	num, err := parseOrderNumber(req.GetOrder())
+	if err != nil {
+		return nil, err
+	}
+
+	sum, err := models.ParseMoney(req.GetSum())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	withdraw := models.WithdrawRequest{Order: num, Sum: sum}
+	if err := withdraw.Validate(); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	if err := s.services.Balance.WithdrawLoyaltyPoints(ctx, userIDFromContext(ctx), withdraw); err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &gophermartpb.WithdrawResponse{}, nil
+}
+
+func (s *Server) ListWithdrawals(ctx context.Context, req *gophermartpb.ListWithdrawalsRequest) (*gophermartpb.ListWithdrawalsResponse, error) {
+	page, err := parsePage(req.GetPageSize(), req.GetPageToken())
+	if err != nil {
+		return nil, err
+	}
+
+	filter := models.WithdrawalFilter{After: page.After, Limit: page.Limit + 1}
+	withdrawals := make([]models.Withdrawal, 0, filter.Limit)
+	err = s.services.Balance.StreamWithdrawals(ctx, userIDFromContext(ctx), filter, func(wd models.Withdrawal) error {
+		withdrawals = append(withdrawals, wd)
+		return nil
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &gophermartpb.ListWithdrawalsResponse{}
+	if len(withdrawals) > page.Limit {
+		withdrawals = withdrawals[:page.Limit]
+		last := withdrawals[page.Limit-1]
+		resp.NextPageToken = models.Cursor{At: last.ProcessedAt, ID: last.ID}.String()
+	}
+	for _, wd := range withdrawals {
+		resp.Withdrawals = append(resp.Withdrawals, &gophermartpb.Withdrawal{
+			Order:       strconv.FormatInt(wd.Order, 10),
+			Sum:         wd.Sum.String(),
+			Status:      wd.Status,
+			Reversed:    wd.Reversed.String(),
+			ProcessedAt: timestamppb.New(wd.ProcessedAt),
+		})
+	}
+	return resp, nil
+}
+
+func parseOrderNumber(raw string) (int64, error) {
+	num, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || !utils.IsValidOrderNum(num) {
+		return 0, status.Error(codes.InvalidArgument, "invalid order number")
+	}
+	return num, nil
+}
+
+// parsePage turns page size and token into a page, the size defaults to
+// defaultPageSize.
+func parsePage(size int32, token string) (models.Page, error) {
+	page := models.Page{Limit: defaultPageSize}
+	if size < 0 || size > maxPageSize {
+		return page, status.Errorf(codes.InvalidArgument, "page size must be between 1 and %d", maxPageSize)
+	}
+	if size > 0 {
+		page.Limit = int(size)
+	}
+	if token != "" {
+		cursor, err := models.ParseCursor(token)
+		if err != nil {
+			return page, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+		page.After = &cursor
+	}
+	return page, nil
+}
+
+func orderToPB(o models.Order) *gophermartpb.Order {
+	return &gophermartpb.Order{
+		Number:       strconv.FormatInt(o.Number, 10),
+		Status:       o.Status,
+		StatusReason: o.StatusReason,
+		Accrual:      o.Accrual.String(),
+		UploadedAt:   timestamppb.New(o.CreatedAt),
+	}
+}
+
+// Shutdown stops srv gracefully. Calls still running after timeout are
+// cancelled, watch streams never end on their own.
+func Shutdown(srv *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		srv.Stop()
+	}
+}
@@ -0,0 +1,337 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/grpcapi/gophermartpb"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testToken = "token"
+
+type testServer struct {
+	client gophermartpb.GophermartClient
+	auth   *mocks.MockAuthorization
+	order  *mocks.MockOrder
+	bal    *mocks.MockBalance
+}
+
+// newTestServer serves the api over an in-memory connection, the test token
+// belongs to the user 1.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+
+	ts := &testServer{
+		auth:  mocks.NewMockAuthorization(ctrl),
+		order: mocks.NewMockOrder(ctrl),
+		bal:   mocks.NewMockBalance(ctrl),
+	}
+	ts.auth.EXPECT().ParseToken(gomock.Any(), testToken).Return(1, nil).AnyTimes()
+
+	services := &service.Service{Authorization: ts.auth, Order: ts.order, Balance: ts.bal}
+	srv := NewGRPCServer(services, WithWatchInterval(10*time.Millisecond))
+
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	ts.client = gophermartpb.NewGophermartClient(conn)
+	return ts
+}
+
+func authorized(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+testToken)
+}
+
+func TestAuthentication(t *testing.T) {
+	ts := newTestServer(t)
+	ts.auth.EXPECT().ParseToken(gomock.Any(), "forged").Return(0, errors.New("bad signature"))
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		expectedCode codes.Code
+	}{
+		{name: "no token", ctx: context.Background(), expectedCode: codes.Unauthenticated},
+		{
+			name:         "not a bearer token",
+			ctx:          metadata.AppendToOutgoingContext(context.Background(), "authorization", testToken),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "invalid token",
+			ctx:          metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer forged"),
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ts.client.GetBalance(tt.ctx, &gophermartpb.GetBalanceRequest{})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := context.Background()
+
+	ts.auth.EXPECT().
+		CreateUser(gomock.Any(), models.User{Login: "gopher", Password: "secret"}).
+		Return(7, nil)
+	ts.auth.EXPECT().GenerateToken(gomock.Any(), "gopher", "secret").Return("jwt", nil).Times(2)
+	ts.auth.EXPECT().GenerateToken(gomock.Any(), "gopher", "wrong").Return("", errors.New("wrong password"))
+	ts.auth.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(0, repository.ErrUserExists)
+
+	resp, err := ts.client.Register(ctx, &gophermartpb.RegisterRequest{Login: "gopher", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), resp.GetUserId())
+	assert.Equal(t, "jwt", resp.GetToken())
+
+	_, err = ts.client.Register(ctx, &gophermartpb.RegisterRequest{Login: "gopher", Password: "secret"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = ts.client.Register(ctx, &gophermartpb.RegisterRequest{Login: "gopher"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	resp, err = ts.client.Login(ctx, &gophermartpb.LoginRequest{Login: "gopher", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "jwt", resp.GetToken())
+
+	_, err = ts.client.Login(ctx, &gophermartpb.LoginRequest{Login: "gopher", Password: "wrong"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestUploadOrder(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		name            string
+		number          string
+		mockSetup       func()
+		expectedCode    codes.Code
+		expectedCreated bool
+	}{
+		{
+			name:   "new order",
+			number: "12345678903",
+			mockSetup: func() {
+				ts.order.EXPECT().
+					CreateOrder(gomock.Any(), models.Order{UserID: 1, Number: 12345678903, Status: models.OrderStatusNew}).
+					Return(&service.ResponseInfo{RespStatusCode: http.StatusAccepted}, nil)
+			},
+			expectedCode:    codes.OK,
+			expectedCreated: true,
+		},
+		{
+			name:   "uploaded before",
+			number: "12345678903",
+			mockSetup: func() {
+				ts.order.EXPECT().
+					CreateOrder(gomock.Any(), gomock.Any()).
+					Return(&service.ResponseInfo{RespStatusCode: http.StatusOK}, nil)
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name:   "uploaded by another user",
+			number: "12345678903",
+			mockSetup: func() {
+				ts.order.EXPECT().
+					CreateOrder(gomock.Any(), gomock.Any()).
+					Return(nil, &service.OrderServiceError{RespStatusCode: http.StatusConflict, ErrMsg: repository.ErrAlreadyExists})
+			},
+			expectedCode: codes.AlreadyExists,
+		},
+		{
+			name:   "internal error",
+			number: "12345678903",
+			mockSetup: func() {
+				ts.order.EXPECT().
+					CreateOrder(gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("pq: connection refused"))
+			},
+			expectedCode: codes.Internal,
+		},
+		{
+			name:         "invalid luhn",
+			number:       "12345678901",
+			mockSetup:    func() {},
+			expectedCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			resp, err := ts.client.UploadOrder(authorized(context.Background()), &gophermartpb.UploadOrderRequest{Number: tt.number})
+
+			require.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.Internal {
+				assert.Equal(t, "internal error", status.Convert(err).Message())
+			}
+			assert.Equal(t, tt.expectedCreated, resp.GetCreated())
+		})
+	}
+}
+
+func TestListOrders(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := authorized(context.Background())
+
+	uploaded := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	after := models.Cursor{At: uploaded.Add(time.Hour), ID: 2377225624}
+
+	ts.order.EXPECT().ListOrders(gomock.Any(), 1, models.Page{Limit: 2}).Return([]models.Order{
+		{Number: 2377225624, Status: models.OrderStatusProcessed, Accrual: 50000, CreatedAt: uploaded.Add(time.Hour)},
+		{Number: 12345678903, Status: models.OrderStatusNew, CreatedAt: uploaded},
+	}, nil)
+	ts.order.EXPECT().ListOrders(gomock.Any(), 1, models.Page{After: &after, Limit: 2}).Return(nil, nil)
+
+	resp, err := ts.client.ListOrders(ctx, &gophermartpb.ListOrdersRequest{PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.GetOrders(), 1)
+	assert.Equal(t, "2377225624", resp.GetOrders()[0].GetNumber())
+	assert.Equal(t, "500.00", resp.GetOrders()[0].GetAccrual())
+	assert.Equal(t, after.String(), resp.GetNextPageToken())
+
+	resp, err = ts.client.ListOrders(ctx, &gophermartpb.ListOrdersRequest{PageSize: 1, PageToken: resp.GetNextPageToken()})
+	require.NoError(t, err)
+	assert.Empty(t, resp.GetOrders())
+	assert.Empty(t, resp.GetNextPageToken())
+
+	_, err = ts.client.ListOrders(ctx, &gophermartpb.ListOrdersRequest{PageToken: "nope"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestWatchOrders(t *testing.T) {
+	ts := newTestServer(t)
+	ctx, cancel := context.WithCancel(authorized(context.Background()))
+	defer cancel()
+
+	uploaded := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	first := models.Order{Number: 12345678903, Status: models.OrderStatusNew, CreatedAt: uploaded}
+	second := models.Order{Number: 2377225624, Status: models.OrderStatusNew, CreatedAt: uploaded.Add(time.Minute)}
+	processed := first
+	processed.Status = models.OrderStatusProcessed
+
+	gomock.InOrder(
+		ts.order.EXPECT().ListOrders(gomock.Any(), 1, models.Page{Limit: watchWindow}).Return([]models.Order{second, first}, nil),
+		ts.order.EXPECT().ListOrders(gomock.Any(), 1, models.Page{Limit: watchWindow}).Return([]models.Order{second, first}, nil),
+		ts.order.EXPECT().ListOrders(gomock.Any(), 1, models.Page{Limit: watchWindow}).Return([]models.Order{second, processed}, nil).AnyTimes(),
+	)
+
+	stream, err := ts.client.WatchOrders(ctx, &gophermartpb.WatchOrdersRequest{})
+	require.NoError(t, err)
+
+	var got []string
+	for range 3 {
+		order, err := stream.Recv()
+		require.NoError(t, err)
+		got = append(got, order.GetNumber()+" "+order.GetStatus())
+	}
+	assert.Equal(t, []string{
+		"12345678903 NEW",
+		"2377225624 NEW",
+		"12345678903 PROCESSED",
+	}, got, "orders are sent oldest first and again only when their status changes")
+
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestWithWatchInterval(t *testing.T) {
+	for _, d := range []time.Duration{0, -time.Second} {
+		s := &Server{watchInterval: defaultWatchInterval}
+		WithWatchInterval(d)(s)
+		assert.Equal(t, defaultWatchInterval, s.watchInterval, "interval %s", d)
+	}
+
+	s := &Server{watchInterval: defaultWatchInterval}
+	WithWatchInterval(time.Second)(s)
+	assert.Equal(t, time.Second, s.watchInterval)
+}
+
+func TestToStatus(t *testing.T) {
+	err := toStatus(context.Background(), fmt.Errorf("withdraw for order 2377225624 of user 7: %w", repository.ErrInsufficientBalance))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Equal(t, repository.ErrInsufficientBalance.Error(), status.Convert(err).Message(),
+		"only the text of the sentinel is sent")
+
+	err = toStatus(context.Background(), errors.New("pq: connection refused"))
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "internal error", status.Convert(err).Message())
+}
+
+func TestBalanceAndWithdrawals(t *testing.T) {
+	ts := newTestServer(t)
+	ctx := authorized(context.Background())
+
+	ts.bal.EXPECT().DisplayUserBalance(gomock.Any(), 1).Return(models.Balance{Current: 50050, Withdrawn: 4200}, nil)
+
+	balance, err := ts.client.GetBalance(ctx, &gophermartpb.GetBalanceRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "500.50", balance.GetCurrent())
+	assert.Equal(t, "42.00", balance.GetWithdrawn())
+
+	ts.bal.EXPECT().
+		WithdrawLoyaltyPoints(gomock.Any(), 1, models.WithdrawRequest{Order: 2377225624, Sum: 75150}).
+		Return(nil)
+	ts.bal.EXPECT().
+		WithdrawLoyaltyPoints(gomock.Any(), 1, models.WithdrawRequest{Order: 2377225624, Sum: 100000}).
+		Return(repository.ErrInsufficientBalance)
+
+	_, err = ts.client.Withdraw(ctx, &gophermartpb.WithdrawRequest{Order: "2377225624", Sum: "751.50"})
+	require.NoError(t, err)
+
+	_, err = ts.client.Withdraw(ctx, &gophermartpb.WithdrawRequest{Order: "2377225624", Sum: "1000"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = ts.client.Withdraw(ctx, &gophermartpb.WithdrawRequest{Order: "2377225624", Sum: "1.234"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	processed := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	ts.bal.EXPECT().
+		StreamWithdrawals(gomock.Any(), 1, models.WithdrawalFilter{Limit: 2}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ int, _ models.WithdrawalFilter, fn func(models.Withdrawal) error) error {
+			fn(models.Withdrawal{ID: 2, Order: 2377225624, Sum: 75150, ProcessedAt: processed.Add(time.Hour)})
+			return fn(models.Withdrawal{ID: 1, Order: 12345678903, Sum: 100, ProcessedAt: processed})
+		})
+
+	resp, err := ts.client.ListWithdrawals(ctx, &gophermartpb.ListWithdrawalsRequest{PageSize: 1})
+	require.NoError(t, err)
+	require.Len(t, resp.GetWithdrawals(), 1)
+	assert.Equal(t, "2377225624", resp.GetWithdrawals()[0].GetOrder())
+	assert.Equal(t, "751.50", resp.GetWithdrawals()[0].GetSum())
+	assert.Equal(t, models.Cursor{At: processed.Add(time.Hour), ID: 2}.String(), resp.GetNextPageToken())
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/go-chi/chi"
+)
+
+type AccrualHandler struct {
+	AccrualRules service.AccrualRules
+}
+
+func NewAccrualHandler(rules service.AccrualRules) *AccrualHandler {
+	return &AccrualHandler{AccrualRules: rules}
+}
+
+func (h *AccrualHandler) AccrualRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/simulate", h.SimulateAccrualHandler)
+	return r
+}
+
+// dry run of the local accrual engine rules
+func (h *AccrualHandler) SimulateAccrualHandler(w http.ResponseWriter, r *http.Request) {
+	if h.AccrualRules == nil {
+		writeProblem(w, r, http.StatusNotImplemented, "local accrual engine is not configured")
+		return
+	}
+
+	var order models.AccrualSimulation
+	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	for _, item := range order.Goods {
+		if item.Price < 0 {
+			writeProblem(w, r, http.StatusBadRequest, "price must not be negative")
+			return
+		}
+	}
+
+	result, err := h.AccrualRules.Simulate(r.Context(), order)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
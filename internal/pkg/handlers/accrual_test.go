@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulateAccrualHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRules := mocks.NewMockAccrualRules(ctrl)
+	handler := NewAccrualHandler(mockRules)
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func()
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			body: `{"goods": [{"description": "Чайник Bork", "category": "bork", "price": 7000}]}`,
+			mockSetup: func() {
+				mockRules.EXPECT().
+					Simulate(gomock.Any(), gomock.Any()).
+					Return(models.AccrualSimulationResult{Accrual: 700, Rules: []models.FiredRule{{ID: "bork", Accrual: 700}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid json",
+			body:           `{invalid`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "negative price",
+			body:           `{"goods": [{"description": "Чайник", "price": -1}]}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "internal error",
+			body: `{"goods": []}`,
+			mockSetup: func() {
+				mockRules.EXPECT().
+					Simulate(gomock.Any(), gomock.Any()).
+					Return(models.AccrualSimulationResult{}, errors.New("engine failure"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/accrual/simulate", bytes.NewBuffer([]byte(tt.body)))
+
+			rec := httptest.NewRecorder()
+			handler.SimulateAccrualHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestSimulateAccrualHandlerNotConfigured(t *testing.T) {
+	handler := NewAccrualHandler(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/admin/accrual/simulate", bytes.NewBuffer([]byte(`{}`)))
+	rec := httptest.NewRecorder()
+	handler.SimulateAccrualHandler(rec, req)
+
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -2,12 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
-	"log"
 	"net/http"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
-	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
 	"github.com/go-chi/chi"
 )
@@ -31,25 +28,22 @@ func (h *AuthHandler) AuthRoutes() chi.Router {
 func (h *AuthHandler) RegisterUserHandler(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
+	user.RegisteredIP = GetClientIP(r)
+
 	ctx := r.Context()
 	id, err := h.AuthService.CreateUser(ctx, user)
 	if err != nil {
-		if errors.Is(err, repository.ErrUserExists) {
-			http.Error(w, "user with the given login already exists", http.StatusConflict)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	token, err := h.AuthService.GenerateToken(ctx, user.Login, user.Password)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, "failed to generate token", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -65,18 +59,39 @@ func (h *AuthHandler) RegisterUserHandler(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(map[string]any{"id": id})
 }
 
+func (h *AuthHandler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.AuthService.ChangePassword(r.Context(), userID, req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 // sign in
 func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	ctx := r.Context()
 	token, err := h.AuthService.GenerateToken(ctx, user.Login, user.Password)
 	if err != nil {
-		http.Error(w, "invalid login/password", http.StatusUnauthorized)
+		writeProblem(w, r, http.StatusUnauthorized, "invalid login/password")
 		return
 	}
 
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"testing"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
@@ -40,6 +41,38 @@ func TestRegisterUserHandler(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "registration with referral code",
+			input: models.User{
+				Login:        "friend",
+				Password:     "password",
+				ReferralCode: "ABCD2345",
+			},
+			mockSetup: func() {
+				mockAuth.EXPECT().
+					CreateUser(gomock.Any(), models.User{
+						Login:        "friend",
+						Password:     "password",
+						ReferralCode: "ABCD2345",
+						RegisteredIP: "192.0.2.1",
+					}).
+					Return(2, nil)
+				mockAuth.EXPECT().GenerateToken(gomock.Any(), "friend", "password").Return("token", nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "invalid referral code",
+			input: models.User{
+				Login:        "friend",
+				Password:     "password",
+				ReferralCode: "NOPE",
+			},
+			mockSetup: func() {
+				mockAuth.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Return(0, repository.ErrInvalidReferralCode)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
 		{
 			name: "user already exists",
 			input: models.User{
@@ -148,3 +181,120 @@ func TestLoginHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestChangePasswordHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuth := mocks.NewMockAuthorization(ctrl)
+	handler := NewAuthHandler(mockAuth)
+
+	tests := []struct {
+		name           string
+		body           string
+		contextUserID  int
+		mockSetup      func()
+		expectedStatus int
+	}{
+		{
+			name:          "success",
+			body:          `{"old_password": "old", "new_password": "new"}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockAuth.EXPECT().
+					ChangePassword(gomock.Any(), 1, models.ChangePasswordRequest{OldPassword: "old", NewPassword: "new"}).
+					Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:          "wrong old password",
+			body:          `{"old_password": "wrong", "new_password": "new"}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockAuth.EXPECT().ChangePassword(gomock.Any(), 1, gomock.Any()).Return(repository.ErrWrongPassword)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:          "same password",
+			body:          `{"old_password": "old", "new_password": "old"}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockAuth.EXPECT().ChangePassword(gomock.Any(), 1, gomock.Any()).Return(models.ErrInvalidPasswordChange)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "invalid json",
+			body:           `{bad`,
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing user id",
+			body:           `{"old_password": "old", "new_password": "new"}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/password", bytes.NewBufferString(tt.body))
+			if tt.contextUserID != 0 {
+				req = addUserToContext(req, tt.contextUserID)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ChangePasswordHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		expectedIP string
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:5100", expectedIP: "203.0.113.7"},
+		{name: "forwarded header of untrusted peer is ignored", remoteAddr: "203.0.113.7:5100",
+			forwarded: []string{"198.51.100.1"}, expectedIP: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.2:5100",
+			forwarded: []string{"198.51.100.1"}, expectedIP: "198.51.100.1"},
+		{name: "spoofed hops left of the client are skipped", remoteAddr: "10.0.0.2:5100",
+			forwarded: []string{"1.1.1.1, 198.51.100.1", "10.0.0.3"}, expectedIP: "198.51.100.1"},
+		{name: "ipv6 proxy", remoteAddr: "[::1]:5100",
+			forwarded: []string{"2001:db8::1"}, expectedIP: "2001:db8::1"},
+		{name: "garbage hop stops the walk", remoteAddr: "10.0.0.2:5100",
+			forwarded: []string{"198.51.100.1, unknown"}, expectedIP: "10.0.0.2"},
+		{name: "only proxies", remoteAddr: "10.0.0.2:5100",
+			forwarded: []string{"10.0.0.4"}, expectedIP: "10.0.0.4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = GetClientIP(r)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/register", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, f := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", f)
+			}
+			ClientIPMiddleware(trusted)(next).ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expectedIP, seen)
+		})
+	}
+}
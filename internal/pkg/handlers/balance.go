@@ -3,39 +3,46 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
 
-	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
-	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/utils"
 	"github.com/go-chi/chi"
 )
 
 type BalanceHandler struct {
-	OrderService           service.Order
-	BalanceService         service.Balance
-	OrderProcessingService service.OrderProcessing
+	BalanceService   service.Balance
+	HoldsService     service.Holds
+	TransfersService service.Transfers
+	TiersService     service.Tiers
 }
 
 type BalanceHandlerOption func(*BalanceHandler)
 
-func WithOrderService(s service.Order) BalanceHandlerOption {
+func WithBalanceService(s service.Balance) BalanceHandlerOption {
 	return func(h *BalanceHandler) {
-		h.OrderService = s
+		h.BalanceService = s
 	}
 }
 
-func WithBalanceService(s service.Balance) BalanceHandlerOption {
+func WithHoldsService(s service.Holds) BalanceHandlerOption {
 	return func(h *BalanceHandler) {
-		h.BalanceService = s
+		h.HoldsService = s
 	}
 }
 
-func WithOrderProcessingService(s service.OrderProcessing) BalanceHandlerOption {
+func WithTransfersService(s service.Transfers) BalanceHandlerOption {
 	return func(h *BalanceHandler) {
-		h.OrderProcessingService = s
+		h.TransfersService = s
+	}
+}
+
+func WithTiersService(s service.Tiers) BalanceHandlerOption {
+	return func(h *BalanceHandler) {
+		h.TiersService = s
 	}
 }
 
@@ -51,6 +58,10 @@ func (h *BalanceHandler) BalanceRoutes() chi.Router {
 	r := chi.NewRouter()
 	r.Get("/", h.UserBalanceHandler)
 	r.Post("/withdraw", h.WithdrawLoyaltyPointsHandler)
+	r.Get("/statement", h.BalanceStatementHandler)
+	r.Mount("/holds", h.HoldsRoutes())
+	r.Post("/transfer", h.TransferPointsHandler)
+	r.Get("/transfers", h.DisplayTransfersHandler)
 	return r
 }
 
@@ -60,20 +71,37 @@ func (h *BalanceHandler) WithdrawalsRoutes() chi.Router {
 	return r
 }
 
+// AdminWithdrawalsRoutes are used by support and merchants to refund
+// purchases paid with points.
+func (h *BalanceHandler) AdminWithdrawalsRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/{order}/reverse", h.ReverseWithdrawalHandler)
+	return r
+}
+
 func (h *BalanceHandler) UserBalanceHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserID(r)
 	if !ok {
-		http.Error(w, "user is is missing in context", http.StatusUnauthorized)
+		writeProblem(w, r, http.StatusUnauthorized, "user is is missing in context")
 		return
 	}
 
 	ctx := r.Context()
 	balance, err := h.BalanceService.DisplayUserBalance(ctx, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	if h.TiersService != nil {
+		tier, err := h.TiersService.UserTier(ctx, userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		balance.Tier = &tier
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(balance)
@@ -82,92 +110,109 @@ func (h *BalanceHandler) UserBalanceHandler(w http.ResponseWriter, r *http.Reque
 func (h *BalanceHandler) WithdrawLoyaltyPointsHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserID(r)
 	if !ok {
-		http.Error(w, "user id is missing in context", http.StatusUnauthorized)
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
 		return
 	}
 
 	if r.Header.Get("Content-Type") != "application/json" {
-		http.Error(w, "invalid content-type", http.StatusUnsupportedMediaType)
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "invalid content-type")
 		return
 	}
 
 	var withdrawInfo models.WithdrawRequest
-	if err := json.NewDecoder(r.Body).Decode(&withdrawInfo); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if !decodeJSON(w, r, &withdrawInfo) {
+		return
+	}
+
+	if err := withdrawInfo.Validate(); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	if !utils.IsValidOrderNum(withdrawInfo.Order) {
-		http.Error(w, "invalid order number", http.StatusUnprocessableEntity)
+		writeProblem(w, r, http.StatusUnprocessableEntity, "invalid order number")
 		return
 	}
 
-	order := models.Order{
-		UserID: userID,
-		Number: withdrawInfo.Order,
-		Status: "NEW",
+	ctx := r.Context()
+	if err := h.BalanceService.WithdrawLoyaltyPoints(ctx, userID, withdrawInfo); err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	respInfo, err := h.OrderService.CreateOrder(r.Context(), order)
-	if err != nil {
-		svcErr, ok := err.(*service.OrderServiceError)
-		if !ok {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+	w.WriteHeader(http.StatusOK)
+}
 
-		if svcErr.RespStatusCode == http.StatusConflict {
-			http.Error(w, svcErr.Error(), http.StatusConflict)
-			return
-		}
-		http.Error(w, svcErr.Error(), svcErr.RespStatusCode)
+const (
+	defaultStatementLimit = 50
+	maxStatementLimit     = 500
+)
+
+func (h *BalanceHandler) BalanceStatementHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
 		return
 	}
 
-	if respInfo.RespStatusCode == http.StatusAccepted {
-		if err := h.OrderProcessingService.EnqueueOrder(r.Context(), order); err != nil {
-			logger.Log.Sugar().Errorf("failed to enqueue order %d, err: %v", order.Number, err)
-			http.Error(w, "failed to enqueue order", http.StatusInternalServerError)
+	limit := defaultStatementLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxStatementLimit {
+			writeProblem(w, r, http.StatusBadRequest, "invalid limit")
 			return
 		}
+		limit = parsed
 	}
 
-	ctx := r.Context()
-	if err := h.BalanceService.WithdrawLoyaltyPoints(ctx, userID, withdrawInfo); err != nil {
-		if errors.Is(err, repository.ErrInsufficientBalance) {
-			http.Error(w, err.Error(), http.StatusPaymentRequired)
-			logger.Log.Sugar().Errorf("Withdraw failed: %v", err)
+	var cursor int64
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			writeProblem(w, r, http.StatusBadRequest, "invalid cursor")
 			return
 		}
-		logger.Log.Sugar().Errorf("Withdrawal failed: %v", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		cursor = parsed
+	}
+
+	ctx := r.Context()
+	statement, err := h.BalanceService.DisplayStatement(ctx, userID, cursor, limit)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(statement)
 }
 
-func (h *BalanceHandler) DisplayUserWithdrawalsHandler(w http.ResponseWriter, r *http.Request) {
-	userID, ok := GetUserID(r)
-	if !ok {
-		http.Error(w, "user id is missing in context", http.StatusUnauthorized)
+func (h *BalanceHandler) ReverseWithdrawalHandler(w http.ResponseWriter, r *http.Request) {
+	order, err := strconv.ParseInt(chi.URLParam(r, "order"), 10, 64)
+	if err != nil || !utils.IsValidOrderNum(order) {
+		writeProblem(w, r, http.StatusUnprocessableEntity, "invalid order number")
 		return
 	}
 
-	ctx := r.Context()
-	userWithdrawals, err := h.BalanceService.DisplayWithdrawals(ctx, userID)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// empty body reverses the whole withdrawal
+	var reversal models.ReversalRequest
+	if err := json.NewDecoder(r.Body).Decode(&reversal); err != nil && !errors.Is(err, io.EOF) {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if len(userWithdrawals) == 0 {
-		w.WriteHeader(http.StatusNoContent)
+	if err := reversal.Validate(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	withdrawal, err := h.BalanceService.ReverseWithdrawal(r.Context(), order, reversal)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(userWithdrawals)
+	json.NewEncoder(w).Encode(withdrawal)
 }
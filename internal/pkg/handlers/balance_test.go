@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
@@ -34,7 +37,7 @@ func TestUserBalanceHandler(t *testing.T) {
 			mockSetup: func() {
 				mockBalance.EXPECT().
 					DisplayUserBalance(gomock.Any(), 123).
-					Return(models.Balance{Current: 100, Withdrawn: 50}, nil)
+					Return(models.Balance{Current: models.MoneyFromFloat(100), Withdrawn: models.MoneyFromFloat(50)}, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -72,19 +75,45 @@ func TestUserBalanceHandler(t *testing.T) {
 	}
 }
 
+func TestUserBalanceHandlerWithTier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBalance := mocks.NewMockBalance(ctrl)
+	mockTiers := mocks.NewMockTiers(ctrl)
+	handler := NewBalanceHandler(WithBalanceService(mockBalance), WithTiersService(mockTiers))
+
+	mockBalance.EXPECT().
+		DisplayUserBalance(gomock.Any(), 123).
+		Return(models.Balance{Current: models.MoneyFromFloat(100)}, nil)
+	mockTiers.EXPECT().
+		UserTier(gomock.Any(), 123).
+		Return(models.TierStatus{
+			Name:           "Silver",
+			Multiplier:     1.1,
+			RollingAccrual: models.MoneyFromFloat(1500),
+			NextTier:       "Gold",
+			ToNextTier:     models.MoneyFromFloat(3500),
+		}, nil)
+
+	req := addUserToContext(httptest.NewRequest(http.MethodGet, "/api/user/balance", nil), 123)
+	rec := httptest.NewRecorder()
+	handler.UserBalanceHandler(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"current": 100, "available": 0, "held": 0, "withdrawn": 0, "expiring_soon": 0,
+		"tier": {"name": "Silver", "multiplier": 1.1, "rolling_accrual": 1500, "next_tier": "Gold", "to_next_tier": 3500}
+	}`, rec.Body.String())
+}
+
 func TestWithdrawLoyaltyPointsHandler(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockBalance := mocks.NewMockBalance(ctrl)
-	mockOrder := mocks.NewMockOrder(ctrl)
-	mockOrderProcessor := mocks.NewMockOrderProcessing(ctrl)
 
-	handler := NewBalanceHandler(
-		WithBalanceService(mockBalance),
-		WithOrderService(mockOrder),
-		WithOrderProcessingService(mockOrderProcessor),
-	)
+	handler := NewBalanceHandler(WithBalanceService(mockBalance))
 
 	body := `{"order": "79927398713", "sum": 50}`
 
@@ -95,6 +124,7 @@ func TestWithdrawLoyaltyPointsHandler(t *testing.T) {
 		contextUserID  int
 		mockSetup      func()
 		expectedStatus int
+		expectedCode   string
 	}{
 		{
 			name:          "success",
@@ -102,13 +132,6 @@ func TestWithdrawLoyaltyPointsHandler(t *testing.T) {
 			contentType:   "application/json",
 			contextUserID: 123,
 			mockSetup: func() {
-				order := models.Order{UserID: 123, Number: 79927398713, Status: "NEW"}
-				mockOrder.EXPECT().
-					CreateOrder(gomock.Any(), order).
-					Return(&service.ResponseInfo{RespStatusCode: http.StatusAccepted}, nil)
-				mockOrderProcessor.EXPECT().
-					EnqueueOrder(gomock.Any(), order).
-					Return(nil)
 				mockBalance.EXPECT().
 					WithdrawLoyaltyPoints(gomock.Any(), 123, gomock.Any()).
 					Return(nil)
@@ -129,7 +152,7 @@ func TestWithdrawLoyaltyPointsHandler(t *testing.T) {
 			contentType:    "application/json",
 			body:           `{invalid json`,
 			mockSetup:      func() {},
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name:           "invalid content-type",
@@ -147,22 +170,110 @@ func TestWithdrawLoyaltyPointsHandler(t *testing.T) {
 			mockSetup:      func() {},
 			expectedStatus: http.StatusUnprocessableEntity,
 		},
+		{
+			name:           "sum with more than two decimal places",
+			contextUserID:  123,
+			contentType:    "application/json",
+			body:           `{"order": "79927398713", "sum": 10.001}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "negative sum",
+			contextUserID:  123,
+			contentType:    "application/json",
+			body:           `{"order": "79927398713", "sum": -10}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:          "withdrawal for the order exists",
+			contextUserID: 123,
+			contentType:   "application/json",
+			body:          body,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					WithdrawLoyaltyPoints(gomock.Any(), 123, models.WithdrawRequest{Order: 79927398713, Sum: models.Money(5000)}).
+					Return(repository.ErrWithdrawalExists)
+			},
+			expectedStatus: http.StatusConflict,
+		},
 		{
 			name:          "insufficient balance",
 			contextUserID: 123,
 			contentType:   "application/json",
 			body:          body,
 			mockSetup: func() {
-				order := models.Order{UserID: 123, Number: 79927398713, Status: "NEW"}
-				mockOrder.EXPECT().
-					CreateOrder(gomock.Any(), order).
-					Return(&service.ResponseInfo{RespStatusCode: http.StatusOK}, nil)
 				mockBalance.EXPECT().
 					WithdrawLoyaltyPoints(gomock.Any(), 123, gomock.Any()).
 					Return(repository.ErrInsufficientBalance)
 			},
 			expectedStatus: http.StatusPaymentRequired,
 		},
+		{
+			name:          "blocked by risk checks",
+			contextUserID: 123,
+			contentType:   "application/json",
+			body:          body,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					WithdrawLoyaltyPoints(gomock.Any(), 123, gomock.Any()).
+					Return(service.ErrRiskBlocked)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:          "below minimum",
+			contextUserID: 123,
+			contentType:   "application/json",
+			body:          body,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					WithdrawLoyaltyPoints(gomock.Any(), 123, gomock.Any()).
+					Return(models.ErrWithdrawalBelowMinimum)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   models.ErrWithdrawalBelowMinimum.Code,
+		},
+		{
+			name:          "daily limit",
+			contextUserID: 123,
+			contentType:   "application/json",
+			body:          body,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					WithdrawLoyaltyPoints(gomock.Any(), 123, gomock.Any()).
+					Return(models.ErrDailyWithdrawalLimit)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedCode:   models.ErrDailyWithdrawalLimit.Code,
+		},
+		{
+			name:          "monthly limit",
+			contextUserID: 123,
+			contentType:   "application/json",
+			body:          body,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					WithdrawLoyaltyPoints(gomock.Any(), 123, gomock.Any()).
+					Return(models.ErrMonthlyWithdrawalLimit)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedCode:   models.ErrMonthlyWithdrawalLimit.Code,
+		},
+		{
+			name:          "password change cooldown",
+			contextUserID: 123,
+			contentType:   "application/json",
+			body:          body,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					WithdrawLoyaltyPoints(gomock.Any(), 123, gomock.Any()).
+					Return(models.ErrPasswordChangeCooldown)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   models.ErrPasswordChangeCooldown.Code,
+		},
 	}
 
 	for _, tt := range tests {
@@ -178,6 +289,9 @@ func TestWithdrawLoyaltyPointsHandler(t *testing.T) {
 			handler.WithdrawLoyaltyPointsHandler(rec, req)
 
 			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedCode != "" {
+				assert.Contains(t, rec.Body.String(), `"code":"`+tt.expectedCode+`"`)
+			}
 		})
 	}
 }
@@ -189,39 +303,261 @@ func TestDisplayUserWithdrawalsHandler(t *testing.T) {
 	mockBalance := mocks.NewMockBalance(ctrl)
 	handler := NewBalanceHandler(WithBalanceService(mockBalance))
 
+	processedAt := time.Date(2025, 7, 10, 12, 0, 0, 0, time.UTC)
+	withdrawals := []models.Withdrawal{
+		{ID: 3, Order: 12345678903, Sum: 5000, Status: models.WithdrawalStatusCompleted, ProcessedAt: processedAt},
+		{ID: 2, Order: 2377225624, Sum: 1050, Status: models.WithdrawalStatusCompleted, ProcessedAt: processedAt.Add(-time.Hour)},
+	}
+	stream := func(rows []models.Withdrawal, err error) func(context.Context, int, models.WithdrawalFilter, func(models.Withdrawal) error) error {
+		return func(_ context.Context, _ int, _ models.WithdrawalFilter, fn func(models.Withdrawal) error) error {
+			for _, wd := range rows {
+				if err := fn(wd); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+	}
+
+	tests := []struct {
+		name                string
+		query               string
+		accept              string
+		contextUserID       int
+		mockSetup           func()
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+		expectedLink        string
+	}{
+		{
+			name:          "success with data",
+			contextUserID: 123,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					StreamWithdrawals(gomock.Any(), 123, models.WithdrawalFilter{}, gomock.Any()).
+					DoAndReturn(stream(withdrawals, nil))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `[{"sum":50.00,"status":"COMPLETED","processed_at":"2025-07-10T12:00:00Z","order":"12345678903"},`,
+		},
+		{
+			name:          "no withdrawals",
+			contextUserID: 123,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					StreamWithdrawals(gomock.Any(), 123, gomock.Any(), gomock.Any()).
+					DoAndReturn(stream(nil, nil))
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:          "filters",
+			query:         "?from=2025-07-01&to=2025-07-10&min_sum=10&max_sum=100.5",
+			contextUserID: 123,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					StreamWithdrawals(gomock.Any(), 123, models.WithdrawalFilter{
+						From:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+						To:     time.Date(2025, 7, 11, 0, 0, 0, 0, time.UTC),
+						MinSum: 1000,
+						MaxSum: 10050,
+					}, gomock.Any()).
+					DoAndReturn(stream(withdrawals, nil))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+		},
+		{
+			name:          "filters with offset",
+			query:         "?from=2025-07-01T03:00:00%2B03:00&to=2025-07-10T12:30:00-04:00",
+			contextUserID: 123,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					StreamWithdrawals(gomock.Any(), 123, models.WithdrawalFilter{
+						From: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+						To:   time.Date(2025, 7, 10, 16, 30, 0, 0, time.UTC),
+					}, gomock.Any()).
+					DoAndReturn(stream(withdrawals, nil))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+		},
+		{
+			name:          "page with next cursor",
+			query:         "?limit=1",
+			contextUserID: 123,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					StreamWithdrawals(gomock.Any(), 123, models.WithdrawalFilter{Limit: 2}, gomock.Any()).
+					DoAndReturn(stream(withdrawals, nil))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedLink: fmt.Sprintf(`</api/user/withdrawals?cursor=%s&limit=1>; rel="next"`,
+				models.Cursor{At: processedAt, ID: 3}),
+		},
+		{
+			name:          "csv export",
+			accept:        "text/csv",
+			contextUserID: 123,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					StreamWithdrawals(gomock.Any(), 123, gomock.Any(), gomock.Any()).
+					DoAndReturn(stream(withdrawals, nil))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody:        "order,sum,status,reversed,processed_at\n12345678903,50.00,COMPLETED,0.00,2025-07-10T12:00:00Z\n",
+		},
+		{
+			name:          "empty csv export",
+			accept:        "text/csv",
+			contextUserID: 123,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					StreamWithdrawals(gomock.Any(), 123, gomock.Any(), gomock.Any()).
+					DoAndReturn(stream(nil, nil))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv",
+			expectedBody:        "order,sum,status,reversed,processed_at\n",
+		},
+		{
+			name:          "ndjson export",
+			accept:        "application/json;q=0.5, application/x-ndjson",
+			contextUserID: 123,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					StreamWithdrawals(gomock.Any(), 123, gomock.Any(), gomock.Any()).
+					DoAndReturn(stream(withdrawals, nil))
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody:        "\"order\":\"12345678903\"}\n{",
+		},
+		{
+			name:           "not acceptable",
+			accept:         "application/xml",
+			contextUserID:  123,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:           "invalid filter",
+			query:          "?from=2025-07-10&to=2025-07-01",
+			contextUserID:  123,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid cursor",
+			query:          "?cursor=nope",
+			contextUserID:  123,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:          "internal error",
+			contextUserID: 123,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					StreamWithdrawals(gomock.Any(), 123, gomock.Any(), gomock.Any()).
+					DoAndReturn(stream(nil, errors.New("db failure")))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "missing user id",
+			contextUserID:  0,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(http.MethodGet, "/api/user/withdrawals"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.contextUserID != 0 {
+				req = addUserToContext(req, tt.contextUserID)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.DisplayUserWithdrawalsHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedContentType != "" {
+				assert.Equal(t, tt.expectedContentType, rec.Header().Get("Content-Type"))
+			}
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+			assert.Equal(t, tt.expectedLink, rec.Header().Get("Link"))
+		})
+	}
+}
+
+func TestBalanceStatementHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBalance := mocks.NewMockBalance(ctrl)
+	handler := NewBalanceHandler(WithBalanceService(mockBalance))
+
 	tests := []struct {
 		name           string
+		query          string
 		contextUserID  int
 		mockSetup      func()
 		expectedStatus int
 	}{
 		{
-			name:          "success with data",
+			name:          "default page",
 			contextUserID: 123,
 			mockSetup: func() {
 				mockBalance.EXPECT().
-					DisplayWithdrawals(gomock.Any(), 123).
-					Return([]models.Withdrawal{{Order: 12345, Sum: 50}}, nil)
+					DisplayStatement(gomock.Any(), 123, int64(0), 50).
+					Return(models.Statement{Entries: []models.LedgerEntry{{ID: 1, Type: models.LedgerEntryAccrual}}}, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
 		{
-			name:          "no withdrawals",
+			name:          "next page",
+			query:         "?limit=10&cursor=42",
 			contextUserID: 123,
 			mockSetup: func() {
 				mockBalance.EXPECT().
-					DisplayWithdrawals(gomock.Any(), 123).
-					Return([]models.Withdrawal{}, nil)
+					DisplayStatement(gomock.Any(), 123, int64(42), 10).
+					Return(models.Statement{Entries: []models.LedgerEntry{}}, nil)
 			},
-			expectedStatus: http.StatusNoContent,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid limit",
+			query:          "?limit=0",
+			contextUserID:  123,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid cursor",
+			query:          "?cursor=abc",
+			contextUserID:  123,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name:          "internal error",
 			contextUserID: 123,
 			mockSetup: func() {
 				mockBalance.EXPECT().
-					DisplayWithdrawals(gomock.Any(), 123).
-					Return(nil, errors.New("db failure"))
+					DisplayStatement(gomock.Any(), 123, int64(0), 50).
+					Return(models.Statement{}, errors.New("db failure"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
@@ -236,13 +572,107 @@ func TestDisplayUserWithdrawalsHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup()
-			req := httptest.NewRequest(http.MethodGet, "/api/user/withdrawals", nil)
+			req := httptest.NewRequest(http.MethodGet, "/api/user/balance/statement"+tt.query, nil)
 			if tt.contextUserID != 0 {
 				req = addUserToContext(req, tt.contextUserID)
 			}
 
 			rec := httptest.NewRecorder()
-			handler.DisplayUserWithdrawalsHandler(rec, req)
+			handler.BalanceStatementHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestReverseWithdrawalHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBalance := mocks.NewMockBalance(ctrl)
+	handler := NewBalanceHandler(WithBalanceService(mockBalance))
+
+	tests := []struct {
+		name           string
+		order          string
+		body           string
+		mockSetup      func()
+		expectedStatus int
+	}{
+		{
+			name:  "partial reversal",
+			order: "79927398713",
+			body:  `{"sum": 100.5, "reason": "item returned"}`,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					ReverseWithdrawal(gomock.Any(), int64(79927398713), models.ReversalRequest{Sum: 10050, Reason: "item returned"}).
+					Return(models.Withdrawal{Order: 79927398713, Sum: 50000, Reversed: 10050, Status: models.WithdrawalStatusCompleted}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:  "full reversal without body",
+			order: "79927398713",
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					ReverseWithdrawal(gomock.Any(), int64(79927398713), models.ReversalRequest{}).
+					Return(models.Withdrawal{Order: 79927398713, Sum: 50000, Reversed: 50000, Status: models.WithdrawalStatusReversed}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid order number",
+			order:          "12345",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "negative sum",
+			order:          "79927398713",
+			body:           `{"sum": -1}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:  "withdrawal not found",
+			order: "79927398713",
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					ReverseWithdrawal(gomock.Any(), int64(79927398713), models.ReversalRequest{}).
+					Return(models.Withdrawal{}, repository.ErrWithdrawalNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:  "already reversed",
+			order: "79927398713",
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					ReverseWithdrawal(gomock.Any(), int64(79927398713), models.ReversalRequest{}).
+					Return(models.Withdrawal{}, repository.ErrWithdrawalNotReversible)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:  "sum exceeds withdrawal",
+			order: "79927398713",
+			body:  `{"sum": 1000}`,
+			mockSetup: func() {
+				mockBalance.EXPECT().
+					ReverseWithdrawal(gomock.Any(), int64(79927398713), models.ReversalRequest{Sum: 100000}).
+					Return(models.Withdrawal{}, repository.ErrReversalExceedsWithdrawal)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(http.MethodPost, "/"+tt.order+"/reverse", bytes.NewBufferString(tt.body))
+
+			rec := httptest.NewRecorder()
+			handler.AdminWithdrawalsRoutes().ServeHTTP(rec, req)
 
 			assert.Equal(t, tt.expectedStatus, rec.Code)
 		})
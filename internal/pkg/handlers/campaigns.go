@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/go-chi/chi"
+)
+
+type CampaignsHandler struct {
+	CampaignsService service.Campaigns
+}
+
+func NewCampaignsHandler(campaigns service.Campaigns) *CampaignsHandler {
+	return &CampaignsHandler{CampaignsService: campaigns}
+}
+
+func (h *CampaignsHandler) CampaignRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.ListCampaignsHandler)
+	r.Post("/", h.CreateCampaignHandler)
+	r.Get("/{id}", h.GetCampaignHandler)
+	r.Put("/{id}", h.UpdateCampaignHandler)
+	r.Delete("/{id}", h.DeleteCampaignHandler)
+	r.Get("/{id}/bonuses", h.ListBonusesHandler)
+	r.Post("/bonuses/{id}/reverse", h.ReverseBonusHandler)
+	return r
+}
+
+func (h *CampaignsHandler) ListCampaignsHandler(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := h.CampaignsService.ListCampaigns(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, campaigns)
+}
+
+func (h *CampaignsHandler) CreateCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	var campaign models.Campaign
+	if err := json.NewDecoder(r.Body).Decode(&campaign); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	created, err := h.CampaignsService.CreateCampaign(r.Context(), campaign)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *CampaignsHandler) GetCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid campaign id")
+		return
+	}
+
+	campaign, err := h.CampaignsService.GetCampaign(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, campaign)
+}
+
+func (h *CampaignsHandler) UpdateCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid campaign id")
+		return
+	}
+
+	var campaign models.Campaign
+	if err := json.NewDecoder(r.Body).Decode(&campaign); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	campaign.ID = id
+
+	updated, err := h.CampaignsService.UpdateCampaign(r.Context(), campaign)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (h *CampaignsHandler) DeleteCampaignHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid campaign id")
+		return
+	}
+
+	if err := h.CampaignsService.DeleteCampaign(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CampaignsHandler) ListBonusesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid campaign id")
+		return
+	}
+
+	bonuses, err := h.CampaignsService.ListBonuses(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, bonuses)
+}
+
+func (h *CampaignsHandler) ReverseBonusHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid bonus id")
+		return
+	}
+
+	bonus, err := h.CampaignsService.ReverseBonus(r.Context(), id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, bonus)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCampaignRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCampaigns := mocks.NewMockCampaigns(ctrl)
+	handler := NewCampaignsHandler(mockCampaigns)
+
+	campaignBody := `{"name": "weekend", "type": "multiplier", "multiplier": 2,
+		"starts_at": "2025-07-12T00:00:00Z", "ends_at": "2025-07-14T00:00:00Z", "active": true}`
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockSetup      func()
+		expectedStatus int
+	}{
+		{
+			name:   "create",
+			method: http.MethodPost,
+			path:   "/",
+			body:   campaignBody,
+			mockSetup: func() {
+				mockCampaigns.EXPECT().CreateCampaign(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, c models.Campaign) (models.Campaign, error) {
+						c.ID = 1
+						return c, nil
+					})
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "create without active",
+			method: http.MethodPost,
+			path:   "/",
+			body:   `{"name": "weekend", "type": "fixed", "points": 100, "starts_at": "2025-07-12T00:00:00Z", "ends_at": "2025-07-14T00:00:00Z"}`,
+			mockSetup: func() {
+				mockCampaigns.EXPECT().CreateCampaign(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, c models.Campaign) (models.Campaign, error) {
+						assert.Nil(t, c.Active)
+						c.ID = 1
+						return c, nil
+					})
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:   "create invalid",
+			method: http.MethodPost,
+			path:   "/",
+			body:   `{"name": "broken", "type": "unknown"}`,
+			mockSetup: func() {
+				mockCampaigns.EXPECT().CreateCampaign(gomock.Any(), gomock.Any()).
+					Return(models.Campaign{}, models.ErrInvalidCampaign)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "create with broken body",
+			method:         http.MethodPost,
+			path:           "/",
+			body:           `{`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "list",
+			method: http.MethodGet,
+			path:   "/",
+			mockSetup: func() {
+				mockCampaigns.EXPECT().ListCampaigns(gomock.Any()).Return([]models.Campaign{{ID: 1, Name: "weekend"}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "get unknown",
+			method: http.MethodGet,
+			path:   "/5",
+			mockSetup: func() {
+				mockCampaigns.EXPECT().GetCampaign(gomock.Any(), 5).Return(models.Campaign{}, repository.ErrCampaignNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "update",
+			method: http.MethodPut,
+			path:   "/1",
+			body:   campaignBody,
+			mockSetup: func() {
+				mockCampaigns.EXPECT().UpdateCampaign(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, c models.Campaign) (models.Campaign, error) {
+						assert.Equal(t, 1, c.ID)
+						return c, nil
+					})
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "delete",
+			method: http.MethodDelete,
+			path:   "/1",
+			mockSetup: func() {
+				mockCampaigns.EXPECT().DeleteCampaign(gomock.Any(), 1).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "bonuses report",
+			method: http.MethodGet,
+			path:   "/1/bonuses",
+			mockSetup: func() {
+				mockCampaigns.EXPECT().ListBonuses(gomock.Any(), 1).Return([]models.CampaignBonus{
+					{ID: 3, CampaignID: 1, UserID: 2, Order: 79927398713, Amount: 5000, Status: models.BonusStatusCredited},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "reverse bonus",
+			method: http.MethodPost,
+			path:   "/bonuses/3/reverse",
+			mockSetup: func() {
+				mockCampaigns.EXPECT().ReverseBonus(gomock.Any(), int64(3)).
+					Return(models.CampaignBonus{ID: 3, Status: models.BonusStatusReversed}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "reverse reversed bonus",
+			method: http.MethodPost,
+			path:   "/bonuses/3/reverse",
+			mockSetup: func() {
+				mockCampaigns.EXPECT().ReverseBonus(gomock.Any(), int64(3)).
+					Return(models.CampaignBonus{}, repository.ErrBonusAlreadyReversed)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:   "storage failure",
+			method: http.MethodGet,
+			path:   "/",
+			mockSetup: func() {
+				mockCampaigns.EXPECT().ListCampaigns(gomock.Any()).Return(nil, errors.New("db failure"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+
+			rec := httptest.NewRecorder()
+			handler.CampaignRoutes().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
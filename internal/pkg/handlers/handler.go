@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"net/http"
+	"net/netip"
+
 	"github.com/0x24CaptainParrot/gophermart-service/internal/config"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/middleware"
@@ -9,48 +12,140 @@ import (
 )
 
 type Handler struct {
-	AuthHandler    *AuthHandler
-	OrdersHandler  *OrderHandler
-	BalanceHandler *BalanceHandler
-	services       *service.Service
-	cfg            *config.Config
+	AuthHandler       *AuthHandler
+	OrdersHandler     *OrderHandler
+	BalanceHandler    *BalanceHandler
+	AccrualHandler    *AccrualHandler
+	CampaignsHandler  *CampaignsHandler
+	ReferralsHandler  *ReferralsHandler
+	StatementsHandler *StatementsHandler
+	RiskHandler       *RiskHandler
+	OpenAPIHandler    *OpenAPIHandler
+	V2Handler         *V2Handler
+	services          *service.Service
+	cfg               *config.Config
 }
 
-func NewHandler(config *config.Config, service *service.Service) *Handler {
+func NewHandler(config *config.Config, service *service.Service, openAPI *OpenAPIHandler) *Handler {
 	return &Handler{
 		AuthHandler:   NewAuthHandler(service.Authorization),
-		OrdersHandler: NewOrderHandler(service.Order, service.OrderProcessing),
+		OrdersHandler: NewOrderHandler(service.Order),
 		BalanceHandler: NewBalanceHandler(
-			WithOrderService(service.Order),
 			WithBalanceService(service.Balance),
-			WithOrderProcessingService(service.OrderProcessing),
+			WithHoldsService(service.Holds),
+			WithTransfersService(service.Transfers),
+			WithTiersService(service.Tiers),
 		),
-		services: service,
-		cfg:      config,
+		AccrualHandler:    NewAccrualHandler(service.AccrualRules),
+		CampaignsHandler:  NewCampaignsHandler(service.Campaigns),
+		ReferralsHandler:  NewReferralsHandler(service.Referrals),
+		StatementsHandler: NewStatementsHandler(service.Statements),
+		RiskHandler:       NewRiskHandler(service.Risk),
+		OpenAPIHandler:    openAPI,
+		V2Handler:         NewV2Handler(service),
+		services:          service,
+		cfg:               config,
 	}
 }
 
 func (h *Handler) InitAPIRoutes() *chi.Mux {
 	r := chi.NewRouter()
+	r.Use(RequestIDMiddleware())
+	r.Use(ClientIPMiddleware(h.trustedProxies()))
 	r.Use(logger.LoggingReqResMiddleware(logger.Log))
 	r.Use(middleware.CompressGzipMiddleware())
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, http.StatusNotFound, "resource not found")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		writeProblem(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	})
 
+	r.Get("/api/openapi.json", h.OpenAPIHandler.SpecHandler)
+	r.Get("/api/docs", h.OpenAPIHandler.SwaggerUIHandler)
+	r.Handle("/api/docs/assets/*", http.StripPrefix("/api/docs/assets/", h.OpenAPIHandler.SwaggerUIAssetsHandler()))
+	// /api/user is the unversioned alias of v1 kept for existing clients
 	r.Mount("/api/user", h.userRouter())
+	r.Mount("/api/v1/user", h.userRouter())
+	r.Mount("/api/v2/user", h.userRouterV2())
+	r.Mount("/api/admin", h.adminRouter())
 
 	return r
 }
 
+// trustedProxies are validated with the config, no proxy is trusted when
+// they turn out broken anyway.
+func (h *Handler) trustedProxies() []netip.Prefix {
+	trusted, err := h.cfg.TrustedProxyPrefixes()
+	if err != nil {
+		logger.Log.Sugar().Errorf("ignoring trusted proxies: %v", err)
+		return nil
+	}
+	return trusted
+}
+
 func (h *Handler) userRouter() chi.Router {
 	r := chi.NewRouter()
+	r.Use(DeprecationMiddleware(v2UserPrefix, v1DeprecatedAt, v1Sunset))
+	// validation runs ahead of authentication: malformed requests get 400
+	// before credentials are looked at, undocumented routes fall through to
+	// the router and get 404 or 405 from it
+	r.Use(h.OpenAPIHandler.ValidateRequestsMiddleware())
 	r.Mount("/", h.AuthHandler.AuthRoutes())
 
 	r.Group(func(r chi.Router) {
 		r.Use(AuthenticateMiddleware(h.services.Authorization))
+		r.Use(IdempotencyMiddleware(h.services.Idempotency))
 
+		r.Post("/password", h.AuthHandler.ChangePasswordHandler)
 		r.Mount("/orders", h.OrdersHandler.OrderRoutes())
 		r.Mount("/balance", h.BalanceHandler.BalanceRoutes())
 		r.Mount("/withdrawals", h.BalanceHandler.WithdrawalsRoutes())
+		r.Mount("/referrals", h.ReferralsHandler.ReferralsRoutes())
+		r.Mount("/statements", h.StatementsHandler.StatementsRoutes())
+	})
+
+	return r
+}
+
+func (h *Handler) userRouterV2() chi.Router {
+	r := chi.NewRouter()
+	// validated ahead of authentication as in v1
+	r.Use(h.OpenAPIHandler.ValidateRequestsMiddleware())
+	r.Post("/register", h.V2Handler.RegisterHandler)
+	r.Post("/login", h.V2Handler.LoginHandler)
+
+	r.Group(func(r chi.Router) {
+		r.Use(AuthenticateMiddleware(h.services.Authorization))
+		r.Use(IdempotencyMiddleware(h.services.Idempotency))
+
+		r.Post("/password", h.V2Handler.ChangePasswordHandler)
+		r.Post("/orders", h.V2Handler.UploadOrderHandler)
+		r.Get("/orders", h.V2Handler.ListOrdersHandler)
+		r.Get("/balance", h.V2Handler.BalanceHandler)
+		r.Post("/balance/withdrawals", h.V2Handler.WithdrawHandler)
+		r.Get("/balance/ledger", h.V2Handler.LedgerHandler)
+		r.Post("/balance/holds", h.V2Handler.CreateHoldHandler)
+		r.Post("/balance/holds/{id}/capture", h.V2Handler.CaptureHoldHandler)
+		r.Post("/balance/holds/{id}/release", h.V2Handler.ReleaseHoldHandler)
+		r.Post("/balance/transfers", h.V2Handler.TransferHandler)
+		r.Get("/balance/transfers", h.V2Handler.ListTransfersHandler)
+		r.Get("/withdrawals", h.V2Handler.ListWithdrawalsHandler)
+		r.Get("/referrals", h.V2Handler.ReferralsHandler)
+		r.Get("/statements/{month}", h.V2Handler.MonthlyStatementHandler)
 	})
 
 	return r
 }
+
+func (h *Handler) adminRouter() chi.Router {
+	r := chi.NewRouter()
+	r.Use(AdminMiddleware(h.cfg.AdminToken))
+
+	r.Mount("/accrual", h.AccrualHandler.AccrualRoutes())
+	r.Mount("/withdrawals", h.BalanceHandler.AdminWithdrawalsRoutes())
+	r.Mount("/campaigns", h.CampaignsHandler.CampaignRoutes())
+	r.Mount("/risk", h.RiskHandler.RiskRoutes())
+
+	return r
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/utils"
+	"github.com/go-chi/chi"
+)
+
+func (h *BalanceHandler) HoldsRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Post("/", h.CreateHoldHandler)
+	r.Post("/{id}/capture", h.CaptureHoldHandler)
+	r.Post("/{id}/release", h.ReleaseHoldHandler)
+	return r
+}
+
+func (h *BalanceHandler) CreateHoldHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	var req models.HoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if errors.Is(err, models.ErrInvalidMoney) || errors.Is(err, models.ErrMoneyPrecision) {
+			writeError(w, r, err)
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	hold, err := h.HoldsService.CreateHold(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hold)
+}
+
+func (h *BalanceHandler) CaptureHoldHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	holdID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid hold id")
+		return
+	}
+
+	var capture models.WithdrawRequest
+	if err := json.NewDecoder(r.Body).Decode(&capture); err != nil {
+		if errors.Is(err, models.ErrInvalidMoney) || errors.Is(err, models.ErrMoneyPrecision) {
+			writeError(w, r, err)
+			return
+		}
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if capture.Sum < 0 {
+		writeError(w, r, models.ErrNegativeMoney)
+		return
+	}
+
+	if !utils.IsValidOrderNum(capture.Order) {
+		writeProblem(w, r, http.StatusUnprocessableEntity, "invalid order number")
+		return
+	}
+
+	hold, err := h.HoldsService.CaptureHold(r.Context(), userID, holdID, capture)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(hold)
+}
+
+func (h *BalanceHandler) ReleaseHoldHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	holdID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid hold id")
+		return
+	}
+
+	hold, err := h.HoldsService.ReleaseHold(r.Context(), userID, holdID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(hold)
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoldsHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHolds := mocks.NewMockHolds(ctrl)
+	handler := NewBalanceHandler(WithHoldsService(mockHolds))
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		contextUserID  int
+		mockSetup      func()
+		expectedStatus int
+	}{
+		{
+			name:          "hold created",
+			path:          "/",
+			body:          `{"sum": 150, "expires_in": 600}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					CreateHold(gomock.Any(), 1, models.HoldRequest{Sum: 15000, ExpiresIn: 600}).
+					Return(models.Hold{ID: 7, Sum: 15000, Status: models.HoldStatusHeld}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "hold of zero points",
+			path:           "/",
+			body:           `{"sum": 0}`,
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:          "not enough available points",
+			path:          "/",
+			body:          `{"sum": 150}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					CreateHold(gomock.Any(), 1, models.HoldRequest{Sum: 15000}).
+					Return(models.Hold{}, repository.ErrInsufficientBalance)
+			},
+			expectedStatus: http.StatusPaymentRequired,
+		},
+		{
+			name:           "expiry too long",
+			path:           "/",
+			body:           `{"sum": 150, "expires_in": 100000}`,
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "expiry overflowing a duration",
+			path:           "/",
+			body:           `{"sum": 150, "expires_in": 9223372036854775807}`,
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "missing user id",
+			path:           "/",
+			body:           `{"sum": 150}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:          "hold captured",
+			path:          "/7/capture",
+			body:          `{"order": "79927398713"}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					CaptureHold(gomock.Any(), 1, int64(7), models.WithdrawRequest{Order: 79927398713}).
+					Return(models.Hold{ID: 7, Sum: 15000, Status: models.HoldStatusCaptured, Order: 79927398713}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "capture with invalid order",
+			path:           "/7/capture",
+			body:           `{"order": "12345"}`,
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:          "capture more than held",
+			path:          "/7/capture",
+			body:          `{"order": "79927398713", "sum": 500}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					CaptureHold(gomock.Any(), 1, int64(7), models.WithdrawRequest{Order: 79927398713, Sum: 50000}).
+					Return(models.Hold{}, repository.ErrCaptureExceedsHold)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:          "capture of expired hold",
+			path:          "/7/capture",
+			body:          `{"order": "79927398713"}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					CaptureHold(gomock.Any(), 1, int64(7), models.WithdrawRequest{Order: 79927398713}).
+					Return(models.Hold{}, repository.ErrHoldNotActive)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:          "capture below withdrawal minimum",
+			path:          "/7/capture",
+			body:          `{"order": "79927398713", "sum": 1}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					CaptureHold(gomock.Any(), 1, int64(7), models.WithdrawRequest{Order: 79927398713, Sum: 100}).
+					Return(models.Hold{}, models.ErrWithdrawalBelowMinimum)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:          "capture over daily withdrawal limit",
+			path:          "/7/capture",
+			body:          `{"order": "79927398713"}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					CaptureHold(gomock.Any(), 1, int64(7), models.WithdrawRequest{Order: 79927398713}).
+					Return(models.Hold{}, models.ErrDailyWithdrawalLimit)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:          "capture during password change cooldown",
+			path:          "/7/capture",
+			body:          `{"order": "79927398713"}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					CaptureHold(gomock.Any(), 1, int64(7), models.WithdrawRequest{Order: 79927398713}).
+					Return(models.Hold{}, models.ErrPasswordChangeCooldown)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:          "capture blocked by risk checks",
+			path:          "/7/capture",
+			body:          `{"order": "79927398713"}`,
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					CaptureHold(gomock.Any(), 1, int64(7), models.WithdrawRequest{Order: 79927398713}).
+					Return(models.Hold{}, service.ErrRiskBlocked)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:          "hold released",
+			path:          "/7/release",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					ReleaseHold(gomock.Any(), 1, int64(7)).
+					Return(models.Hold{ID: 7, Sum: 15000, Status: models.HoldStatusReleased}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:          "release of unknown hold",
+			path:          "/8/release",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					ReleaseHold(gomock.Any(), 1, int64(8)).
+					Return(models.Hold{}, repository.ErrHoldNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:          "release failure",
+			path:          "/7/release",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockHolds.EXPECT().
+					ReleaseHold(gomock.Any(), 1, int64(7)).
+					Return(models.Hold{}, errors.New("db failure"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "invalid hold id",
+			path:           "/abc/release",
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			if tt.contextUserID != 0 {
+				req = addUserToContext(req, tt.contextUserID)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.HoldsRoutes().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLen      = 255
+)
+
+// IdempotencyMiddleware makes POST requests carrying an Idempotency-Key header
+// safe to retry: the first response is stored and replayed for the same key.
+// Requests without the header are passed through untouched.
+func IdempotencyMiddleware(idempotency service.Idempotency) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if idempotency == nil || r.Method != http.MethodPost || key == "" {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLen {
+				writeProblem(w, r, http.StatusBadRequest, "idempotency key is too long")
+				return
+			}
+
+			userID, ok := GetUserID(r)
+			if !ok {
+				writeProblem(w, r, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeProblem(w, r, http.StatusBadRequest, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			record, err := idempotency.Begin(r.Context(), userID, key, requestHash(r, body))
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			if record != nil {
+				if record.ContentType != "" {
+					w.Header().Set("Content-Type", record.ContentType)
+				}
+				w.Header().Set(idempotencyReplayedHeader, "true")
+				w.WriteHeader(record.StatusCode)
+				w.Write(record.Body)
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			h.ServeHTTP(rec, r)
+
+			// the client may have gone away by now, the key must still be
+			// settled or it stays in progress until it expires
+			ctx := context.WithoutCancel(r.Context())
+
+			// server errors are not stored, so the client can retry them
+			if rec.statusCode >= http.StatusInternalServerError {
+				if err := idempotency.Release(ctx, userID, key); err != nil {
+					logger.Log.Sugar().Errorf("failed to release idempotency key: %v", err)
+				}
+				return
+			}
+
+			err = idempotency.Complete(ctx, userID, key, models.IdempotencyRecord{
+				StatusCode:  rec.statusCode,
+				ContentType: rec.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+			})
+			if err != nil {
+				logger.Log.Sugar().Errorf("failed to store idempotent response: %v", err)
+			}
+		})
+	}
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(r.URL.Path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes the response through and keeps a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(statusCode int) {
+	if !rr.wroteHeader {
+		rr.statusCode = statusCode
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdempotency := mocks.NewMockIdempotency(ctrl)
+
+	var calls int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("accepted"))
+	})
+	failing := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+	})
+
+	tests := []struct {
+		name           string
+		key            string
+		next           http.Handler
+		mockSetup      func()
+		expectedStatus int
+		expectedCalls  int
+		expectedBody   string
+		replayed       bool
+	}{
+		{
+			name:           "no key",
+			next:           next,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusAccepted,
+			expectedCalls:  1,
+			expectedBody:   "accepted",
+		},
+		{
+			name: "first request is stored",
+			key:  "key-1",
+			next: next,
+			mockSetup: func() {
+				mockIdempotency.EXPECT().Begin(gomock.Any(), 1, "key-1", gomock.Any()).Return(nil, nil)
+				mockIdempotency.EXPECT().Complete(gomock.Any(), 1, "key-1", models.IdempotencyRecord{
+					StatusCode: http.StatusAccepted,
+					Body:       []byte("accepted"),
+				}).Return(nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedCalls:  1,
+			expectedBody:   "accepted",
+		},
+		{
+			name: "completed request is replayed",
+			key:  "key-1",
+			next: next,
+			mockSetup: func() {
+				mockIdempotency.EXPECT().Begin(gomock.Any(), 1, "key-1", gomock.Any()).Return(&models.IdempotencyRecord{
+					StatusCode:  http.StatusOK,
+					ContentType: "application/json",
+					Body:        []byte(`{"ok":true}`),
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"ok":true}`,
+			replayed:       true,
+		},
+		{
+			name: "key reused with another request",
+			key:  "key-1",
+			next: next,
+			mockSetup: func() {
+				mockIdempotency.EXPECT().Begin(gomock.Any(), 1, "key-1", gomock.Any()).Return(nil, service.ErrIdempotencyKeyReused)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "request in progress",
+			key:  "key-1",
+			next: next,
+			mockSetup: func() {
+				mockIdempotency.EXPECT().Begin(gomock.Any(), 1, "key-1", gomock.Any()).Return(nil, service.ErrIdempotencyKeyInProcess)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "storage error",
+			key:  "key-1",
+			next: next,
+			mockSetup: func() {
+				mockIdempotency.EXPECT().Begin(gomock.Any(), 1, "key-1", gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "server error releases the key",
+			key:  "key-1",
+			next: failing,
+			mockSetup: func() {
+				mockIdempotency.EXPECT().Begin(gomock.Any(), 1, "key-1", gomock.Any()).Return(nil, nil)
+				mockIdempotency.EXPECT().Release(gomock.Any(), 1, "key-1").Return(nil)
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			tt.mockSetup()
+
+			req := httptest.NewRequest(http.MethodPost, "/balance/withdraw", bytes.NewBufferString(`{"order":"2377225624","sum":751}`))
+			if tt.key != "" {
+				req.Header.Set(idempotencyKeyHeader, tt.key)
+			}
+			req = addUserToContext(req, 1)
+
+			rec := httptest.NewRecorder()
+			IdempotencyMiddleware(mockIdempotency)(tt.next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedCalls, calls)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rec.Body.String())
+			}
+			if tt.replayed {
+				assert.Equal(t, "true", rec.Header().Get(idempotencyReplayedHeader))
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddlewareCanceledRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIdempotency := mocks.NewMockIdempotency(ctrl)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		mockSetup  func()
+	}{
+		{
+			name:       "response is stored",
+			statusCode: http.StatusAccepted,
+			mockSetup: func() {
+				mockIdempotency.EXPECT().Complete(gomock.Any(), 1, "key-1", gomock.Any()).
+					DoAndReturn(func(ctx context.Context, userID int, key string, record models.IdempotencyRecord) error {
+						assert.NoError(t, ctx.Err())
+						return nil
+					})
+			},
+		},
+		{
+			name:       "key is released",
+			statusCode: http.StatusInternalServerError,
+			mockSetup: func() {
+				mockIdempotency.EXPECT().Release(gomock.Any(), 1, "key-1").
+					DoAndReturn(func(ctx context.Context, userID int, key string) error {
+						assert.NoError(t, ctx.Err())
+						return nil
+					})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockIdempotency.EXPECT().Begin(gomock.Any(), 1, "key-1", gomock.Any()).Return(nil, nil)
+			tt.mockSetup()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// the client goes away before the handler returns, the key must
+			// still be settled
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				cancel()
+				w.WriteHeader(tt.statusCode)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/balance/withdraw", bytes.NewBufferString(`{"order":"2377225624","sum":751}`))
+			req = req.WithContext(ctx)
+			req.Header.Set(idempotencyKeyHeader, "key-1")
+			req = addUserToContext(req, 1)
+
+			rec := httptest.NewRecorder()
+			IdempotencyMiddleware(mockIdempotency)(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.statusCode, rec.Code)
+		})
+	}
+}
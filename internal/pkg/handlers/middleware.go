@@ -2,15 +2,26 @@ package handlers
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
 	"net/http"
+	"net/netip"
 	"strings"
+	"time"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
 )
 
 type ctxKey string
 
-const userIDKey ctxKey = "userID"
+const (
+	userIDKey    ctxKey = "userID"
+	requestIDKey ctxKey = "requestID"
+	clientIPKey  ctxKey = "clientIP"
+)
 
 func GetUserID(r *http.Request) (int, bool) {
 	idRaw := r.Context().Value(userIDKey)
@@ -37,13 +48,13 @@ func AuthenticateMiddleware(authService service.Authorization) func(http.Handler
 			}
 
 			if token == "" {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				writeProblem(w, r, http.StatusUnauthorized, "unauthorized")
 				return
 			}
 
 			userID, err := authService.ParseToken(r.Context(), token)
 			if err != nil {
-				http.Error(w, "invalid token", http.StatusUnauthorized)
+				writeProblem(w, r, http.StatusUnauthorized, "invalid token")
 				return
 			}
 
@@ -52,3 +63,164 @@ func AuthenticateMiddleware(authService service.Authorization) func(http.Handler
 		})
 	}
 }
+
+const adminTokenHeader = "X-Admin-Token"
+
+// AdminMiddleware lets through requests carrying the configured admin token,
+// admin api is disabled when no token is configured.
+func AdminMiddleware(adminToken string) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if adminToken == "" {
+				writeProblem(w, r, http.StatusForbidden, "admin api is disabled")
+				return
+			}
+
+			token := r.Header.Get(adminTokenHeader)
+			if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+				writeProblem(w, r, http.StatusUnauthorized, "invalid admin token")
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 64
+)
+
+func GetRequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey).(string)
+	return id
+}
+
+// RequestIDMiddleware keeps the request id sent by the client when it looks
+// sane and generates one otherwise. The id is echoed in the response header.
+func RequestIDMiddleware() func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestIDHeader)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+
+			w.Header().Set(requestIDHeader, id)
+			ctx := context.WithValue(r.Context(), requestIDKey, id)
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// GetClientIP returns the client address resolved by ClientIPMiddleware, the
+// address of the peer without the middleware.
+func GetClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(clientIPKey).(string); ok {
+		return ip
+	}
+	host, _, _ := net.SplitHostPort(r.RemoteAddr)
+	return host
+}
+
+// ClientIPMiddleware resolves the client address. X-Forwarded-For is only
+// looked at for requests coming from a trusted proxy, the client is the
+// rightmost address in it that is not a trusted proxy itself.
+func ClientIPMiddleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolveClientIP(r, trusted)
+			ctx := context.WithValue(r.Context(), clientIPKey, ip)
+			h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func resolveClientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return ""
+	}
+	if !isTrustedProxy(host, trusted) {
+		return host
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(header, ",") {
+			hops = append(hops, strings.TrimSpace(hop))
+		}
+	}
+
+	client := host
+	for i := len(hops) - 1; i >= 0; i-- {
+		if _, err := netip.ParseAddr(hops[i]); err != nil {
+			break
+		}
+		client = hops[i]
+		if !isTrustedProxy(client, trusted) {
+			break
+		}
+	}
+	return client
+}
+
+func isTrustedProxy(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+const v2UserPrefix = "/api/v2/user"
+
+// v1 was deprecated when v2 shipped and is kept for six more months.
+var (
+	v1DeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	v1Sunset       = time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC)
+)
+
+// DeprecationMiddleware marks responses of a deprecated api version with the
+// date of deprecation (RFC 9745) and of removal (RFC 8594), and links its
+// successor.
+func DeprecationMiddleware(successor string, deprecatedAt, sunset time.Time) func(http.Handler) http.Handler {
+	link := fmt.Sprintf(`<%s>; rel="successor-version"`, successor)
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Set("Sunset", sunsetDate)
+			w.Header().Add("Link", link)
+			h.ServeHTTP(w, r)
+		})
+	}
+}
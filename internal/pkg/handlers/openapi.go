@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/openapi"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+const problemCodeInvalidRequest = "invalid_request"
+
+type OpenAPIHandler struct {
+	spec   []byte
+	router routers.Router
+}
+
+func NewOpenAPIHandler(doc *openapi3.T) (*OpenAPIHandler, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode openapi document: %w", err)
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build openapi router: %w", err)
+	}
+	return &OpenAPIHandler{spec: spec, router: router}, nil
+}
+
+func (h *OpenAPIHandler) SpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(h.spec)
+}
+
+func (h *OpenAPIHandler) SwaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openapi.SwaggerUI)
+}
+
+// SwaggerUIAssetsHandler serves the vendored Swagger UI files, it expects the
+// path to be stripped down to the file name.
+func (h *OpenAPIHandler) SwaggerUIAssetsHandler() http.Handler {
+	return http.FileServer(http.FS(openapi.SwaggerUIAssets))
+}
+
+// ValidateRequestsMiddleware checks content types, parameters and bodies of
+// requests against the document. Authentication is left to
+// AuthenticateMiddleware, business rules to the handlers. Requests to routes
+// missing from the document are passed through to the router. It is mounted
+// ahead of AuthenticateMiddleware, so a malformed request is rejected with 400
+// even without credentials.
+func (h *OpenAPIHandler) ValidateRequestsMiddleware() func(http.Handler) http.Handler {
+	options := &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := h.router.FindRoute(routeRequest(r))
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if body := route.Operation.RequestBody; body != nil && (body.Value.Required || r.ContentLength > 0) {
+				mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+				if body.Value.Content.Get(mediaType) == nil {
+					writeProblem(w, r, http.StatusUnsupportedMediaType,
+						fmt.Sprintf("content type %q is not supported", mediaType))
+					return
+				}
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				writeProblemCode(w, r, http.StatusBadRequest, problemCodeInvalidRequest, validationDetail(err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// v1UserPrefix serves the same api the document describes under /api/user.
+const v1UserPrefix = "/api/v1/user"
+
+// routeRequest maps the request on its documented path: /api/v1/user is
+// validated as /api/user and the trailing slash chi tolerates on mounted
+// routes is dropped, so /api/user/orders/ is validated as /api/user/orders.
+func routeRequest(r *http.Request) *http.Request {
+	path := r.URL.Path
+	if rest, ok := strings.CutPrefix(path, v1UserPrefix); ok && (rest == "" || rest[0] == '/') {
+		path = "/api/user" + rest
+	}
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	if path == r.URL.Path {
+		return r
+	}
+	routed := r.Clone(r.Context())
+	routed.URL.Path = path
+	routed.URL.RawPath = ""
+	return routed
+}
+
+// validationDetail names the offending parameter or body field without the
+// schema dump the validator errors carry.
+func validationDetail(err error) string {
+	reason := err.Error()
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		reason = schemaErr.Reason
+		if pointer := schemaErr.JSONPointer(); len(pointer) > 0 {
+			reason = fmt.Sprintf("%s: %s", strings.Join(pointer, "."), reason)
+		}
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) {
+		if schemaErr == nil {
+			reason = reqErr.Reason
+			if reqErr.Err != nil {
+				reason = reqErr.Err.Error()
+			}
+		}
+		switch {
+		case reqErr.Parameter != nil:
+			return fmt.Sprintf("invalid %s parameter %s: %s", reqErr.Parameter.In, reqErr.Parameter.Name, reason)
+		case reqErr.RequestBody != nil:
+			return "invalid request body: " + reason
+		}
+	}
+	return reason
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/config"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/openapi"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOpenAPIHandler(t *testing.T) *OpenAPIHandler {
+	t.Helper()
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	h, err := NewOpenAPIHandler(doc)
+	require.NoError(t, err)
+	return h
+}
+
+// every user route has to be documented and every documented operation routed
+func TestOpenAPICoversUserRoutes(t *testing.T) {
+	doc, err := openapi.Load()
+	require.NoError(t, err)
+	openAPI, err := NewOpenAPIHandler(doc)
+	require.NoError(t, err)
+
+	handler := NewHandler(&config.Config{}, &service.Service{}, openAPI)
+	routed := make(map[string]bool)
+	err = chi.Walk(handler.InitAPIRoutes(), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, "/api/user/") && !strings.HasPrefix(route, "/api/v1/user/") &&
+			!strings.HasPrefix(route, "/api/v2/user/") {
+			return nil
+		}
+		// v1 is documented under its unversioned alias
+		path := strings.Replace(route, "/api/v1/user/", "/api/user/", 1)
+		path = strings.TrimSuffix(strings.ReplaceAll(path, "/*", ""), "/")
+		routed[method+" "+path] = true
+
+		item := doc.Paths.Find(path)
+		if assert.NotNil(t, item, "route %s %s is missing from the openapi document", method, path) {
+			assert.NotNil(t, item.GetOperation(method), "operation %s %s is missing from the openapi document", method, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, routed)
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			assert.True(t, routed[method+" "+path], "documented operation %s %s is not routed", method, path)
+		}
+	}
+}
+
+func TestValidateRequestsMiddleware(t *testing.T) {
+	validate := newTestOpenAPIHandler(t).ValidateRequestsMiddleware()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedDetail string
+	}{
+		{
+			name:           "valid withdrawal",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order": "2377225624", "sum": 751}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "sum as string",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/withdraw",
+			contentType:    "application/json; charset=utf-8",
+			body:           `{"order": "2377225624", "sum": "751.50"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing sum",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order": "2377225624"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: `property "sum" is missing`,
+		},
+		{
+			name:           "order number as number",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order": 2377225624, "sum": 751}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "order",
+		},
+		{
+			name:           "unsupported content type",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/withdraw",
+			contentType:    "text/plain",
+			body:           `{"order": "2377225624", "sum": 751}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "order upload",
+			method:         http.MethodPost,
+			path:           "/api/user/orders",
+			contentType:    "text/plain",
+			body:           "12345678903",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "order upload with trailing slash",
+			method:         http.MethodPost,
+			path:           "/api/user/orders/",
+			contentType:    "application/json",
+			body:           `"12345678903"`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "invalid hold id",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/holds/abc/release",
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "parameter id",
+		},
+		{
+			name:           "invalid statement month",
+			method:         http.MethodGet,
+			path:           "/api/user/statements/june",
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "parameter month",
+		},
+		{
+			name:           "limit out of range",
+			method:         http.MethodGet,
+			path:           "/api/user/withdrawals?limit=1000",
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "parameter limit",
+		},
+		{
+			name:           "v1 route is validated as documented",
+			method:         http.MethodPost,
+			path:           "/api/v1/user/balance/withdraw",
+			contentType:    "application/json",
+			body:           `{"order": "2377225624"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: `property "sum" is missing`,
+		},
+		{
+			name:           "v2 order upload",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/orders",
+			contentType:    "application/json",
+			body:           `{"number": "12345678903"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 order upload as text",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/orders",
+			contentType:    "text/plain",
+			body:           "12345678903",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "v2 page limit out of range",
+			method:         http.MethodGet,
+			path:           "/api/v2/user/balance/transfers?limit=0",
+			expectedStatus: http.StatusBadRequest,
+			expectedDetail: "parameter limit",
+		},
+		{
+			name:           "undocumented route is passed through",
+			method:         http.MethodGet,
+			path:           "/api/user/unknown",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body []byte
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body = make([]byte, r.ContentLength)
+				r.Body.Read(body)
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			validate(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.body, string(body), "the body has to reach the handler")
+				return
+			}
+
+			var problem Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			if tt.expectedStatus == http.StatusBadRequest {
+				assert.Equal(t, problemCodeInvalidRequest, problem.Code)
+			}
+			assert.Contains(t, problem.Detail, tt.expectedDetail)
+		})
+	}
+}
+
+// validation runs ahead of authentication and leaves unknown routes to the
+// router
+func TestValidationMiddlewareOrder(t *testing.T) {
+	handler := NewHandler(&config.Config{}, &service.Service{}, newTestOpenAPIHandler(t))
+	router := handler.InitAPIRoutes()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "malformed body without credentials",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/balance/withdrawals",
+			body:           `{"order": 2377225624}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   problemCodeInvalidRequest,
+		},
+		{
+			name:           "valid body without credentials",
+			method:         http.MethodPost,
+			path:           "/api/v2/user/balance/withdrawals",
+			body:           `{"order": "2377225624", "sum": 751}`,
+			expectedStatus: http.StatusUnauthorized,
+			expectedCode:   "unauthorized",
+		},
+		{
+			name:           "v1 malformed body without credentials",
+			method:         http.MethodPost,
+			path:           "/api/user/balance/withdraw",
+			body:           `{"order": 2377225624}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   problemCodeInvalidRequest,
+		},
+		{
+			name:           "unknown route",
+			method:         http.MethodGet,
+			path:           "/api/v2/user/unknown",
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			var problem Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, tt.expectedCode, problem.Code)
+		})
+	}
+}
+
+func TestOpenAPIDocumentHandlers(t *testing.T) {
+	h := newTestOpenAPIHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.SpecHandler(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var spec map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+
+	rec = httptest.NewRecorder()
+	h.SwaggerUIHandler(rec, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `url: "/api/openapi.json"`)
+	assert.NotContains(t, rec.Body.String(), "https://", "the page must not load assets from elsewhere")
+
+	for _, asset := range []string{"swagger-ui.css", "swagger-ui-bundle.js"} {
+		rec = httptest.NewRecorder()
+		http.StripPrefix("/api/docs/assets/", h.SwaggerUIAssetsHandler()).
+			ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs/assets/"+asset, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, asset)
+		assert.NotZero(t, rec.Body.Len(), asset)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/utils"
@@ -16,14 +15,12 @@ import (
 )
 
 type OrderHandler struct {
-	OrderService           service.Order
-	OrderProcessingService service.OrderProcessing
+	OrderService service.Order
 }
 
-func NewOrderHandler(order service.Order, processOrders service.OrderProcessing) *OrderHandler {
+func NewOrderHandler(order service.Order) *OrderHandler {
 	return &OrderHandler{
-		OrderService:           order,
-		OrderProcessingService: processOrders,
+		OrderService: order,
 	}
 }
 
@@ -37,25 +34,25 @@ func (h *OrderHandler) OrderRoutes() chi.Router {
 func (h *OrderHandler) ProcessUserOrderHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserID(r)
 	if !ok {
-		http.Error(w, "user id is missing in context", http.StatusUnauthorized)
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
 		return
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, "failed to read body request", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "failed to read body request")
 		return
 	}
 	defer r.Body.Close()
 
 	num, err := strconv.ParseInt(string(body), 10, 64)
 	if err != nil {
-		http.Error(w, "invalid order number", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, "invalid order number")
 		return
 	}
 
 	if !utils.IsValidOrderNum(num) {
-		http.Error(w, "invalid order number", http.StatusUnprocessableEntity)
+		writeProblem(w, r, http.StatusUnprocessableEntity, "invalid order number")
 		return
 	}
 
@@ -68,42 +65,29 @@ func (h *OrderHandler) ProcessUserOrderHandler(w http.ResponseWriter, r *http.Re
 	ctx := r.Context()
 	respInfo, err := h.OrderService.CreateOrder(ctx, order)
 	if err != nil {
-		var svcErr *service.OrderServiceError
-		if errors.As(err, &svcErr) {
-			http.Error(w, svcErr.Error(), svcErr.RespStatusCode)
-			return
-		}
-		http.Error(w, "failed to return the server response", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	if respInfo.RespStatusCode == http.StatusAccepted {
-		if err := h.OrderProcessingService.EnqueueOrder(r.Context(), order); err != nil {
-			logger.Log.Sugar().Errorf("failed to enqueue order %d, err: %v", order.Number, err)
-			http.Error(w, "failed to enqueue order", http.StatusInternalServerError)
-			return
-		}
-	}
-
 	w.WriteHeader(respInfo.RespStatusCode)
 }
 
 func (h *OrderHandler) UserOrdersHandler(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserID(r)
 	if !ok {
-		http.Error(w, "user id is missing in context", http.StatusUnauthorized)
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
 		return
 	}
 
 	ctx := r.Context()
-	orders, err := h.OrderService.ListOrders(ctx, userID)
+	orders, err := h.OrderService.ListOrders(ctx, userID, models.Page{})
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
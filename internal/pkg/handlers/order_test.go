@@ -27,8 +27,7 @@ func TestProcessUserOrderHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockOrder := mocks.NewMockOrder(ctrl)
-	mockOrderProcessor := mocks.NewMockOrderProcessing(ctrl)
-	handler := NewOrderHandler(mockOrder, mockOrderProcessor)
+	handler := NewOrderHandler(mockOrder)
 
 	validOrder := int64(79927398713)
 
@@ -47,9 +46,6 @@ func TestProcessUserOrderHandler(t *testing.T) {
 				mockOrder.EXPECT().
 					CreateOrder(gomock.Any(), gomock.Any()).
 					Return(&service.ResponseInfo{RespStatusCode: http.StatusAccepted}, nil)
-				mockOrderProcessor.EXPECT().
-					EnqueueOrder(gomock.Any(), gomock.Any()).
-					Return(nil)
 			},
 			expectedStatus: http.StatusAccepted,
 		},
@@ -122,7 +118,7 @@ func TestUserOrdersHandler(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockOrder := mocks.NewMockOrder(ctrl)
-	handler := NewOrderHandler(mockOrder, nil)
+	handler := NewOrderHandler(mockOrder)
 
 	tests := []struct {
 		name           string
@@ -135,7 +131,7 @@ func TestUserOrdersHandler(t *testing.T) {
 			contextUserID: 123,
 			mockSetup: func() {
 				mockOrder.EXPECT().
-					ListOrders(gomock.Any(), 123).
+					ListOrders(gomock.Any(), 123, models.Page{}).
 					Return([]models.Order{{Number: 123456}}, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -145,7 +141,7 @@ func TestUserOrdersHandler(t *testing.T) {
 			contextUserID: 123,
 			mockSetup: func() {
 				mockOrder.EXPECT().
-					ListOrders(gomock.Any(), 123).
+					ListOrders(gomock.Any(), 123, models.Page{}).
 					Return([]models.Order{}, nil)
 			},
 			expectedStatus: http.StatusNoContent,
@@ -155,7 +151,7 @@ func TestUserOrdersHandler(t *testing.T) {
 			contextUserID: 123,
 			mockSetup: func() {
 				mockOrder.EXPECT().
-					ListOrders(gomock.Any(), 123).
+					ListOrders(gomock.Any(), 123, models.Page{}).
 					Return(nil, sql.ErrNoRows)
 			},
 			expectedStatus: http.StatusNoContent,
@@ -165,7 +161,7 @@ func TestUserOrdersHandler(t *testing.T) {
 			contextUserID: 123,
 			mockSetup: func() {
 				mockOrder.EXPECT().
-					ListOrders(gomock.Any(), 123).
+					ListOrders(gomock.Any(), 123, models.Page{}).
 					Return(nil, errors.New("db failure"))
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object. Code is a stable machine
+// readable identifier of the problem, clients should rely on it rather than on
+// Detail.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Codes of problems that are not caused by a known error.
+const (
+	problemCodeInternal = "internal_error"
+)
+
+type problemMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// problemMappings translate sentinel errors of the service and repository
+// layers into problems, the first matching entry wins.
+var problemMappings = []problemMapping{
+	{repository.ErrUserExists, http.StatusConflict, "user_exists"},
+	{repository.ErrInvalidReferralCode, http.StatusUnprocessableEntity, "invalid_referral_code"},
+	{repository.ErrWrongPassword, http.StatusForbidden, "wrong_password"},
+	{models.ErrInvalidPasswordChange, http.StatusUnprocessableEntity, "invalid_password_change"},
+
+	{models.ErrNegativeMoney, http.StatusUnprocessableEntity, "negative_amount"},
+	{models.ErrMoneyPrecision, http.StatusUnprocessableEntity, "invalid_amount_precision"},
+	{models.ErrInvalidMoney, http.StatusUnprocessableEntity, "invalid_amount"},
+
+	{repository.ErrAlreadyExists, http.StatusConflict, "order_owned_by_another_user"},
+	{service.ErrRiskBlocked, http.StatusForbidden, "risk_blocked"},
+	{repository.ErrInsufficientBalance, http.StatusPaymentRequired, "insufficient_balance"},
+	{repository.ErrWithdrawalExists, http.StatusConflict, "withdrawal_exists"},
+	{repository.ErrWithdrawalNotFound, http.StatusNotFound, "withdrawal_not_found"},
+	{repository.ErrWithdrawalNotReversible, http.StatusConflict, "withdrawal_not_reversible"},
+	{repository.ErrReversalExceedsWithdrawal, http.StatusUnprocessableEntity, "reversal_exceeds_withdrawal"},
+	{models.ErrInvalidFilter, http.StatusBadRequest, "invalid_filter"},
+	{models.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+
+	{models.ErrInvalidHold, http.StatusUnprocessableEntity, "invalid_hold"},
+	{service.ErrHoldTTLTooLong, http.StatusUnprocessableEntity, "hold_ttl_too_long"},
+	{repository.ErrCaptureExceedsHold, http.StatusUnprocessableEntity, "capture_exceeds_hold"},
+	{repository.ErrHoldNotFound, http.StatusNotFound, "hold_not_found"},
+	{repository.ErrHoldNotActive, http.StatusConflict, "hold_not_active"},
+
+	{models.ErrInvalidTransfer, http.StatusUnprocessableEntity, "invalid_transfer"},
+	{repository.ErrRecipientNotFound, http.StatusNotFound, "recipient_not_found"},
+	{repository.ErrSelfTransfer, http.StatusUnprocessableEntity, "self_transfer"},
+	{repository.ErrTransferLimitExceeded, http.StatusUnprocessableEntity, "transfer_limit_exceeded"},
+
+	{models.ErrInvalidCampaign, http.StatusUnprocessableEntity, "invalid_campaign"},
+	{repository.ErrCampaignNotFound, http.StatusNotFound, "campaign_not_found"},
+	{repository.ErrBonusNotFound, http.StatusNotFound, "bonus_not_found"},
+	{repository.ErrBonusAlreadyReversed, http.StatusConflict, "bonus_already_reversed"},
+
+	{models.ErrInvalidStatementMonth, http.StatusBadRequest, "invalid_statement_month"},
+	{service.ErrStatementMonthInFuture, http.StatusNotFound, "statement_not_available"},
+
+	{models.ErrInvalidResolution, http.StatusUnprocessableEntity, "invalid_resolution"},
+	{repository.ErrReviewNotFound, http.StatusNotFound, "review_not_found"},
+	{repository.ErrReviewAlreadyResolved, http.StatusConflict, "review_already_resolved"},
+
+	{service.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, "idempotency_key_reused"},
+	{service.ErrIdempotencyKeyInProcess, http.StatusConflict, "idempotency_key_in_process"},
+}
+
+// writeError answers with the problem matching err. Unknown errors become
+// internal errors, their details are logged along with the request id and not
+// sent to the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var limitErr *models.LimitError
+	if errors.As(err, &limitErr) {
+		writeProblemCode(w, r, limitErrorStatus(limitErr), limitErr.Code, limitErr.Message)
+		return
+	}
+
+	for _, m := range problemMappings {
+		if errors.Is(err, m.err) {
+			writeProblemCode(w, r, m.status, m.code, err.Error())
+			return
+		}
+	}
+
+	var svcErr *service.OrderServiceError
+	if errors.As(err, &svcErr) && svcErr.RespStatusCode < http.StatusInternalServerError {
+		writeProblem(w, r, svcErr.RespStatusCode, svcErr.Error())
+		return
+	}
+
+	logger.Log.Sugar().Errorw("request failed", "request_id", GetRequestID(r),
+		"method", r.Method, "path", r.URL.Path, "error", err)
+	writeProblemCode(w, r, http.StatusInternalServerError, problemCodeInternal, "")
+}
+
+// limitErrorStatus tells the rejections apart by status: amount limits are
+// unprocessable, velocity caps are rate limits.
+func limitErrorStatus(err *models.LimitError) int {
+	switch err {
+	case models.ErrDailyWithdrawalLimit, models.ErrMonthlyWithdrawalLimit:
+		return http.StatusTooManyRequests
+	case models.ErrPasswordChangeCooldown:
+		return http.StatusForbidden
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// writeProblem answers with a problem coded after the status, e.g.
+// "unsupported_media_type".
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	code := strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+	writeProblemCode(w, r, status, code, detail)
+}
+
+// writeProblemCode drops the details of server errors, they are never meant
+// for the client. Other 5xx statuses keep their code.
+func writeProblemCode(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	if status >= http.StatusInternalServerError {
+		detail = ""
+	}
+	if status == http.StatusInternalServerError {
+		code = problemCodeInternal
+	}
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: GetRequestID(r),
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedDetail string
+	}{
+		{
+			name:           "sentinel",
+			err:            repository.ErrUserExists,
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "user_exists",
+			expectedDetail: repository.ErrUserExists.Error(),
+		},
+		{
+			name:           "wrapped sentinel",
+			err:            fmt.Errorf("withdraw: %w", repository.ErrInsufficientBalance),
+			expectedStatus: http.StatusPaymentRequired,
+			expectedCode:   "insufficient_balance",
+			expectedDetail: "withdraw: insufficient loyalty points",
+		},
+		{
+			name:           "order service error",
+			err:            &service.OrderServiceError{RespStatusCode: http.StatusConflict, ErrMsg: repository.ErrAlreadyExists},
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "order_owned_by_another_user",
+			expectedDetail: repository.ErrAlreadyExists.Error(),
+		},
+		{
+			name:           "limit error",
+			err:            models.ErrDailyWithdrawalLimit,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedCode:   models.ErrDailyWithdrawalLimit.Code,
+			expectedDetail: models.ErrDailyWithdrawalLimit.Message,
+		},
+		{
+			name:           "internal error hides details",
+			err:            errors.New("pq: connection refused"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "internal_error",
+		},
+		{
+			name:           "order service internal error hides details",
+			err:            &service.OrderServiceError{RespStatusCode: http.StatusInternalServerError, ErrMsg: errors.New("pq: deadlock")},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/user/balance/withdraw", nil)
+			req.Header.Set(requestIDHeader, "req-1")
+			rec := httptest.NewRecorder()
+
+			RequestIDMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeError(w, r, tt.err)
+			})).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, problemContentType, rec.Header().Get("Content-Type"))
+
+			var problem Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, tt.expectedStatus, problem.Status)
+			assert.Equal(t, tt.expectedCode, problem.Code)
+			assert.Equal(t, tt.expectedDetail, problem.Detail)
+			assert.Equal(t, "/api/user/balance/withdraw", problem.Instance)
+			assert.Equal(t, "req-1", problem.RequestID)
+		})
+	}
+}
+
+func TestWriteProblemHidesServerErrorDetails(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/risk/reviews", nil)
+	rec := httptest.NewRecorder()
+	writeProblem(rec, req, http.StatusNotImplemented, "risk config not found at /etc/gophermart/risk.json")
+
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+
+	var problem Problem
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, "not_implemented", problem.Code)
+	assert.Empty(t, problem.Detail)
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "client id is kept", requestID: "abc-123_x.y", keep: true},
+		{name: "missing id is generated"},
+		{name: "malformed id is replaced", requestID: "abc\r\nSet-Cookie: x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = GetRequestID(r)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/user/balance", nil)
+			if tt.requestID != "" {
+				req.Header.Set(requestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			RequestIDMiddleware()(next).ServeHTTP(rec, req)
+
+			assert.NotEmpty(t, seen)
+			assert.Equal(t, seen, rec.Header().Get(requestIDHeader))
+			if tt.keep {
+				assert.Equal(t, tt.requestID, seen)
+			} else {
+				assert.NotEqual(t, tt.requestID, seen)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/go-chi/chi"
+)
+
+type ReferralsHandler struct {
+	ReferralsService service.Referrals
+}
+
+func NewReferralsHandler(referrals service.Referrals) *ReferralsHandler {
+	return &ReferralsHandler{ReferralsService: referrals}
+}
+
+func (h *ReferralsHandler) ReferralsRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", h.DisplayReferralsHandler)
+	return r
+}
+
+func (h *ReferralsHandler) DisplayReferralsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	referrals, err := h.ReferralsService.ListReferrals(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, referrals)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayReferralsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReferrals := mocks.NewMockReferrals(ctrl)
+	handler := NewReferralsHandler(mockReferrals)
+
+	tests := []struct {
+		name           string
+		contextUserID  int
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:          "success",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockReferrals.EXPECT().ListReferrals(gomock.Any(), 1).Return(models.Referrals{
+					Code: "ABCD2345",
+					Referrals: []models.Referral{
+						{Login: "friend", Status: models.ReferralStatusPending, Reward: 10000},
+						{Login: "twin", Status: models.ReferralStatusRejected, Reason: models.ReferralReasonSameIP, Reward: 10000},
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"code":"ABCD2345"`,
+		},
+		{
+			name:          "internal error",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockReferrals.EXPECT().ListReferrals(gomock.Any(), 1).Return(models.Referrals{}, errors.New("db failure"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "missing user id",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(http.MethodGet, "/api/user/referrals", nil)
+			if tt.contextUserID != 0 {
+				req = addUserToContext(req, tt.contextUserID)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.DisplayReferralsHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/go-chi/chi"
+)
+
+type RiskHandler struct {
+	RiskService service.Risk
+}
+
+func NewRiskHandler(risk service.Risk) *RiskHandler {
+	return &RiskHandler{RiskService: risk}
+}
+
+// RiskRoutes is the review queue of flagged and blocked events. Reviews are
+// advisory, resolving one does not touch the points or orders involved.
+func (h *RiskHandler) RiskRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if h.RiskService == nil {
+				writeProblem(w, r, http.StatusNotImplemented, "risk checks are not configured")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	r.Get("/reviews", h.ListReviewsHandler)
+	r.Post("/reviews/{id}/resolve", h.ResolveReviewHandler)
+	return r
+}
+
+func (h *RiskHandler) ListReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.ReviewStatusPending, models.ReviewStatusApproved, models.ReviewStatusRejected:
+	default:
+		writeProblem(w, r, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	reviews, err := h.RiskService.ListReviews(r.Context(), status)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, reviews)
+}
+
+func (h *RiskHandler) ResolveReviewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid review id")
+		return
+	}
+
+	var resolution models.ReviewResolution
+	if err := json.NewDecoder(r.Body).Decode(&resolution); err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	review, err := h.RiskService.ResolveReview(r.Context(), id, resolution)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, review)
+}
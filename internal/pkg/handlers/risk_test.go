@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskRoutes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRisk := mocks.NewMockRisk(ctrl)
+	handler := NewRiskHandler(mockRisk)
+
+	review := models.RiskReview{
+		ID:       1,
+		Event:    models.RiskEvent{Kind: models.RiskEventOrderUpload, UserID: 7, Order: 12345678903},
+		Decision: models.RiskDecision{Score: 90, Action: models.RiskActionBlock, Reasons: []string{"upload_velocity"}},
+		Status:   models.ReviewStatusPending,
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		mockSetup      func()
+		expectedStatus int
+	}{
+		{
+			name:   "list pending",
+			method: http.MethodGet,
+			path:   "/reviews?status=PENDING",
+			mockSetup: func() {
+				mockRisk.EXPECT().ListReviews(gomock.Any(), models.ReviewStatusPending).Return([]models.RiskReview{review}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "list with invalid status",
+			method:         http.MethodGet,
+			path:           "/reviews?status=DONE",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "list error",
+			method: http.MethodGet,
+			path:   "/reviews",
+			mockSetup: func() {
+				mockRisk.EXPECT().ListReviews(gomock.Any(), "").Return(nil, errors.New("db failure"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:   "resolve",
+			method: http.MethodPost,
+			path:   "/reviews/1/resolve",
+			body:   `{"status": "REJECTED", "note": "bogus receipts"}`,
+			mockSetup: func() {
+				resolved := review
+				resolved.Status = models.ReviewStatusRejected
+				mockRisk.EXPECT().
+					ResolveReview(gomock.Any(), int64(1), models.ReviewResolution{Status: models.ReviewStatusRejected, Note: "bogus receipts"}).
+					Return(resolved, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:   "resolve with invalid status",
+			method: http.MethodPost,
+			path:   "/reviews/1/resolve",
+			body:   `{"status": "PENDING"}`,
+			mockSetup: func() {
+				mockRisk.EXPECT().ResolveReview(gomock.Any(), int64(1), gomock.Any()).
+					Return(models.RiskReview{}, models.ErrInvalidResolution)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "resolve unknown review",
+			method: http.MethodPost,
+			path:   "/reviews/2/resolve",
+			body:   `{"status": "APPROVED"}`,
+			mockSetup: func() {
+				mockRisk.EXPECT().ResolveReview(gomock.Any(), int64(2), gomock.Any()).
+					Return(models.RiskReview{}, repository.ErrReviewNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:   "resolve twice",
+			method: http.MethodPost,
+			path:   "/reviews/1/resolve",
+			body:   `{"status": "APPROVED"}`,
+			mockSetup: func() {
+				mockRisk.EXPECT().ResolveReview(gomock.Any(), int64(1), gomock.Any()).
+					Return(models.RiskReview{}, repository.ErrReviewAlreadyResolved)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "resolve with invalid id",
+			method:         http.MethodPost,
+			path:           "/reviews/abc/resolve",
+			body:           `{"status": "APPROVED"}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+			handler.RiskRoutes().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestRiskRoutesNotConfigured(t *testing.T) {
+	handler := NewRiskHandler(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/reviews", nil)
+	rec := httptest.NewRecorder()
+	handler.RiskRoutes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotImplemented, rec.Code)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/go-chi/chi"
+)
+
+const contentTypeHTML = "text/html"
+
+type StatementsHandler struct {
+	StatementsService service.Statements
+}
+
+func NewStatementsHandler(statements service.Statements) *StatementsHandler {
+	return &StatementsHandler{StatementsService: statements}
+}
+
+func (h *StatementsHandler) StatementsRoutes() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/{month}", h.MonthlyStatementHandler)
+	return r
+}
+
+// MonthlyStatementHandler returns the statement as JSON or, for browsers, as
+// a printable HTML document.
+func (h *StatementsHandler) MonthlyStatementHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	format := negotiateContentType(r.Header.Get("Accept"), contentTypeJSON, contentTypeHTML)
+	if format == "" {
+		writeProblem(w, r, http.StatusNotAcceptable, "unsupported accept header")
+		return
+	}
+
+	month, err := models.ParseStatementMonth(chi.URLParam(r, "month"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	statement, err := h.StatementsService.MonthlyStatement(r.Context(), userID, month)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if format == contentTypeJSON {
+		writeJSON(w, http.StatusOK, statement)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if err := statementTemplate.Execute(w, statement); err != nil {
+		logger.Log.Sugar().Errorf("failed to render statement: %v", err)
+	}
+}
+
+var statementTemplate = template.Must(template.New("statement").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Statement {{.Month}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #999; padding: 0.3em 0.8em; }
+td.num { text-align: right; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Loyalty points statement for {{.Month}}</h1>
+<table>
+<tr><th>Opening balance</th><td class="num">{{.OpeningBalance}}</td></tr>
+<tr><th>Credits</th><td class="num">{{.Credits}}</td></tr>
+<tr><th>Debits</th><td class="num">{{.Debits}}</td></tr>
+<tr><th>Closing balance</th><td class="num">{{.ClosingBalance}}</td></tr>
+</table>
+{{if .Totals}}
+<h2>Totals</h2>
+<table>
+<tr><th>Type</th><th>Credits</th><th>Debits</th></tr>
+{{range .Totals}}<tr><td>{{.Type}}</td><td class="num">{{.Credits}}</td><td class="num">{{.Debits}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Orders}}
+<h2>Processed orders</h2>
+<table>
+<tr><th>Order</th><th>Accrual</th><th>Processed at</th></tr>
+{{range .Orders}}<tr><td>{{.Number}}</td><td class="num">{{.Accrual}}</td><td>{{.ProcessedAt.Format "2006-01-02 15:04"}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Withdrawals}}
+<h2>Withdrawals</h2>
+<table>
+<tr><th>Order</th><th>Sum</th><th>Processed at</th></tr>
+{{range .Withdrawals}}<tr><td>{{.Order}}</td><td class="num">{{.Sum}}</td><td>{{.ProcessedAt.Format "2006-01-02 15:04"}}</td></tr>
+{{end}}</table>
+{{end}}
+<p>Generated at {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+</body>
+</html>
+`))
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonthlyStatementHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStatements := mocks.NewMockStatements(ctrl)
+	handler := NewStatementsHandler(mockStatements)
+
+	june := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	statement := models.MonthlyStatement{
+		Month:          "2025-06",
+		OpeningBalance: 10000,
+		Credits:        5000,
+		Debits:         2000,
+		ClosingBalance: 13000,
+		Totals: []models.StatementTotal{
+			{Type: models.LedgerEntryAccrual, Credits: 5000},
+			{Type: models.LedgerEntryWithdrawal, Debits: 2000},
+		},
+		Orders:      []models.StatementOrder{{Number: 12345678903, Accrual: 5000, ProcessedAt: june.AddDate(0, 0, 3)}},
+		Withdrawals: []models.StatementWithdrawal{{Order: 2377225624, Sum: 2000, ProcessedAt: june.AddDate(0, 0, 10)}},
+		GeneratedAt: june.AddDate(0, 1, 0),
+	}
+
+	tests := []struct {
+		name                string
+		month               string
+		accept              string
+		contextUserID       int
+		mockSetup           func()
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:          "json",
+			month:         "2025-06",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockStatements.EXPECT().MonthlyStatement(gomock.Any(), 1, june).Return(statement, nil)
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        `"closing_balance":130.00`,
+		},
+		{
+			name:          "html",
+			month:         "2025-06",
+			accept:        "text/html,application/xhtml+xml,*/*;q=0.8",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockStatements.EXPECT().MonthlyStatement(gomock.Any(), 1, june).Return(statement, nil)
+			},
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        `<tr><th>Closing balance</th><td class="num">130.00</td></tr>`,
+		},
+		{
+			name:           "invalid month",
+			month:          "2025-13",
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:          "future month",
+			month:         "2999-01",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockStatements.EXPECT().MonthlyStatement(gomock.Any(), 1, gomock.Any()).
+					Return(models.MonthlyStatement{}, service.ErrStatementMonthInFuture)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:          "internal error",
+			month:         "2025-06",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockStatements.EXPECT().MonthlyStatement(gomock.Any(), 1, june).
+					Return(models.MonthlyStatement{}, errors.New("db failure"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "not acceptable",
+			month:          "2025-06",
+			accept:         "application/pdf",
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:           "missing user id",
+			month:          "2025-06",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(http.MethodGet, "/"+tt.month, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			if tt.contextUserID != 0 {
+				req = addUserToContext(req, tt.contextUserID)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.StatementsRoutes().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedContentType != "" {
+				assert.Equal(t, tt.expectedContentType, rec.Header().Get("Content-Type"))
+			}
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
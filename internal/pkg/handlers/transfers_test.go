@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferPointsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTransfers := mocks.NewMockTransfers(ctrl)
+	handler := NewBalanceHandler(WithTransfersService(mockTransfers))
+
+	tests := []struct {
+		name           string
+		body           string
+		contentType    string
+		contextUserID  int
+		mockSetup      func()
+		expectedStatus int
+	}{
+		{
+			name:          "success",
+			body:          `{"recipient": "mom", "sum": 25.5, "note": "happy birthday"}`,
+			contentType:   "application/json",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockTransfers.EXPECT().
+					Transfer(gomock.Any(), 1, models.TransferRequest{Recipient: "mom", Sum: 2550, Note: "happy birthday"}).
+					Return(models.Transfer{ID: 1, Direction: models.TransferOut, Counterparty: "mom", Sum: 2550}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing recipient",
+			body:           `{"sum": 25}`,
+			contentType:    "application/json",
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "wrong content type",
+			body:           `{"recipient": "mom", "sum": 25}`,
+			contentType:    "text/plain",
+			contextUserID:  1,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:          "insufficient balance",
+			body:          `{"recipient": "mom", "sum": 25}`,
+			contentType:   "application/json",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockTransfers.EXPECT().
+					Transfer(gomock.Any(), 1, models.TransferRequest{Recipient: "mom", Sum: 2500}).
+					Return(models.Transfer{}, repository.ErrInsufficientBalance)
+			},
+			expectedStatus: http.StatusPaymentRequired,
+		},
+		{
+			name:          "unknown recipient",
+			body:          `{"recipient": "nobody", "sum": 25}`,
+			contentType:   "application/json",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockTransfers.EXPECT().
+					Transfer(gomock.Any(), 1, models.TransferRequest{Recipient: "nobody", Sum: 2500}).
+					Return(models.Transfer{}, repository.ErrRecipientNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:          "daily limit exceeded",
+			body:          `{"recipient": "mom", "sum": 25}`,
+			contentType:   "application/json",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockTransfers.EXPECT().
+					Transfer(gomock.Any(), 1, models.TransferRequest{Recipient: "mom", Sum: 2500}).
+					Return(models.Transfer{}, repository.ErrTransferLimitExceeded)
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:          "blocked by risk checks",
+			body:          `{"recipient": "mom", "sum": 25}`,
+			contentType:   "application/json",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockTransfers.EXPECT().
+					Transfer(gomock.Any(), 1, models.TransferRequest{Recipient: "mom", Sum: 2500}).
+					Return(models.Transfer{}, service.ErrRiskBlocked)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:          "monthly withdrawal limit exceeded",
+			body:          `{"recipient": "mom", "sum": 25}`,
+			contentType:   "application/json",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockTransfers.EXPECT().
+					Transfer(gomock.Any(), 1, models.TransferRequest{Recipient: "mom", Sum: 2500}).
+					Return(models.Transfer{}, models.ErrMonthlyWithdrawalLimit)
+			},
+			expectedStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:          "internal error",
+			body:          `{"recipient": "mom", "sum": 25}`,
+			contentType:   "application/json",
+			contextUserID: 1,
+			mockSetup: func() {
+				mockTransfers.EXPECT().
+					Transfer(gomock.Any(), 1, models.TransferRequest{Recipient: "mom", Sum: 2500}).
+					Return(models.Transfer{}, errors.New("db failure"))
+			},
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "missing user id",
+			body:           `{"recipient": "mom", "sum": 25}`,
+			contentType:    "application/json",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(http.MethodPost, "/api/user/balance/transfer", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.contextUserID != 0 {
+				req = addUserToContext(req, tt.contextUserID)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.TransferPointsHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestDisplayTransfersHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTransfers := mocks.NewMockTransfers(ctrl)
+	handler := NewBalanceHandler(WithTransfersService(mockTransfers))
+
+	tests := []struct {
+		name           string
+		mockSetup      func()
+		expectedStatus int
+	}{
+		{
+			name: "both directions",
+			mockSetup: func() {
+				mockTransfers.EXPECT().ListTransfers(gomock.Any(), 1, models.Page{}).Return([]models.Transfer{
+					{ID: 2, Direction: models.TransferIn, Counterparty: "mom", Sum: 1000},
+					{ID: 1, Direction: models.TransferOut, Counterparty: "dad", Sum: 500},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "no transfers",
+			mockSetup: func() {
+				mockTransfers.EXPECT().ListTransfers(gomock.Any(), 1, models.Page{}).Return([]models.Transfer{}, nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := addUserToContext(httptest.NewRequest(http.MethodGet, "/api/user/balance/transfers", nil), 1)
+
+			rec := httptest.NewRecorder()
+			handler.DisplayTransfersHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
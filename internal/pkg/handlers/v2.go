@@ -0,0 +1,603 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/utils"
+	"github.com/go-chi/chi"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// V2Handler serves the second version of the user api. It shares services
+// with the first one but always answers with JSON: resources are wrapped in an
+// envelope, lists are paged with opaque cursors and failures are problems.
+type V2Handler struct {
+	AuthService       service.Authorization
+	OrderService      service.Order
+	BalanceService    service.Balance
+	HoldsService      service.Holds
+	TransfersService  service.Transfers
+	TiersService      service.Tiers
+	ReferralsService  service.Referrals
+	StatementsService service.Statements
+}
+
+func NewV2Handler(services *service.Service) *V2Handler {
+	return &V2Handler{
+		AuthService:       services.Authorization,
+		OrderService:      services.Order,
+		BalanceService:    services.Balance,
+		HoldsService:      services.Holds,
+		TransfersService:  services.Transfers,
+		TiersService:      services.Tiers,
+		ReferralsService:  services.Referrals,
+		StatementsService: services.Statements,
+	}
+}
+
+// envelope wraps every successful v2 response body, Pagination is set for
+// lists only.
+type envelope struct {
+	Data       any         `json:"data"`
+	Pagination *pagination `json:"pagination,omitempty"`
+}
+
+// pagination tells how to get the next page, NextCursor is empty on the last
+// one.
+type pagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+func writeData(w http.ResponseWriter, status int, data any) {
+	writeJSON(w, status, envelope{Data: data})
+}
+
+func writePage(w http.ResponseWriter, data any, page pagination) {
+	writeJSON(w, http.StatusOK, envelope{Data: data, Pagination: &page})
+}
+
+// parsePage reads limit and cursor, the limit defaults to defaultPageLimit.
+func parsePage(query url.Values) (models.Page, error) {
+	page := models.Page{Limit: defaultPageLimit}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, fmt.Errorf("%w: malformed limit", models.ErrInvalidFilter)
+		}
+		page.Limit = limit
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := models.ParseCursor(raw)
+		if err != nil {
+			return page, err
+		}
+		page.After = &cursor
+	}
+	return page, nil
+}
+
+// cutPage trims items fetched with one extra row to the page limit and points
+// the cursor at the last item kept when there is a next page.
+func cutPage[T any](items []T, limit int, cursor func(T) models.Cursor) ([]T, pagination) {
+	page := pagination{Limit: limit}
+	if len(items) > limit {
+		items = items[:limit]
+		page.NextCursor = cursor(items[limit-1]).String()
+	}
+	return items, page
+}
+
+// decodeJSON reads the request body into v, malformed amounts keep their own
+// problems.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		if errors.Is(err, models.ErrInvalidMoney) || errors.Is(err, models.ErrMoneyPrecision) {
+			writeError(w, r, err)
+			return false
+		}
+		writeProblem(w, r, http.StatusBadRequest, "invalid request body")
+		return false
+	}
+	return true
+}
+
+func setAuthCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "Authorization",
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+	})
+}
+
+func (h *V2Handler) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	if !decodeJSON(w, r, &user) {
+		return
+	}
+
+	user.RegisteredIP = GetClientIP(r)
+
+	ctx := r.Context()
+	id, err := h.AuthService.CreateUser(ctx, user)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	token, err := h.AuthService.GenerateToken(ctx, user.Login, user.Password)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	setAuthCookie(w, token)
+	writeData(w, http.StatusCreated, map[string]any{"id": id, "token": token})
+}
+
+func (h *V2Handler) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	if !decodeJSON(w, r, &user) {
+		return
+	}
+
+	token, err := h.AuthService.GenerateToken(r.Context(), user.Login, user.Password)
+	if err != nil {
+		writeProblemCode(w, r, http.StatusUnauthorized, "invalid_credentials", "invalid login/password")
+		return
+	}
+
+	setAuthCookie(w, token)
+	writeData(w, http.StatusOK, map[string]any{"token": token})
+}
+
+func (h *V2Handler) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := h.AuthService.ChangePassword(r.Context(), userID, req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// orderUpload is the body of an order upload, goods are optional and feed
+// the rules of the local accrual engine.
+type orderUpload struct {
+	Number int64              `json:"number,string"`
+	Goods  []models.OrderItem `json:"goods,omitempty"`
+}
+
+// orderUploadData is the data of an order upload response.
+type orderUploadData struct {
+	Number int64  `json:"number,string"`
+	Status string `json:"status,omitempty"`
+}
+
+// UploadOrderHandler answers 202 for a new order and 200 for an order the user
+// uploaded before.
+func (h *V2Handler) UploadOrderHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	var upload orderUpload
+	if !decodeJSON(w, r, &upload) {
+		return
+	}
+
+	if !utils.IsValidOrderNum(upload.Number) {
+		writeProblemCode(w, r, http.StatusUnprocessableEntity, "invalid_order_number", "invalid order number")
+		return
+	}
+	for _, item := range upload.Goods {
+		if item.Price < 0 {
+			writeError(w, r, models.ErrNegativeMoney)
+			return
+		}
+	}
+
+	order := models.Order{
+		UserID: userID,
+		Number: upload.Number,
+		Status: models.OrderStatusNew,
+		Goods:  upload.Goods,
+	}
+	respInfo, err := h.OrderService.CreateOrder(r.Context(), order)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	data := orderUploadData{Number: upload.Number}
+	if respInfo.RespStatusCode == http.StatusAccepted {
+		data.Status = models.OrderStatusNew
+	}
+	writeData(w, respInfo.RespStatusCode, data)
+}
+
+// orderData is an order along with the breakdown of its accrual, which v1
+// leaves out.
+type orderData struct {
+	Number       int64        `json:"number,string"`
+	Status       string       `json:"status,omitempty"`
+	StatusReason string       `json:"status_reason,omitempty"`
+	Accrual      models.Money `json:"accrual"`
+	BaseAccrual  models.Money `json:"base_accrual,omitempty"`
+	Multiplier   float64      `json:"multiplier,omitempty"`
+	Bonus        models.Money `json:"bonus,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
+
+func newOrderData(o models.Order) orderData {
+	return orderData{
+		Number:       o.Number,
+		Status:       o.Status,
+		StatusReason: o.StatusReason,
+		Accrual:      o.Accrual,
+		BaseAccrual:  o.BaseAccrual,
+		Multiplier:   o.Multiplier,
+		Bonus:        o.Bonus,
+		CreatedAt:    o.CreatedAt,
+	}
+}
+
+func (h *V2Handler) ListOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	limit := page.Limit
+	page.Limit++
+	orders, err := h.OrderService.ListOrders(r.Context(), userID, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	orders, next := cutPage(orders, limit, func(o models.Order) models.Cursor {
+		return models.Cursor{At: o.CreatedAt, ID: o.Number}
+	})
+	data := make([]orderData, 0, len(orders))
+	for _, order := range orders {
+		data = append(data, newOrderData(order))
+	}
+	writePage(w, data, next)
+}
+
+func (h *V2Handler) BalanceHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	ctx := r.Context()
+	balance, err := h.BalanceService.DisplayUserBalance(ctx, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if h.TiersService != nil {
+		tier, err := h.TiersService.UserTier(ctx, userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		balance.Tier = &tier
+	}
+
+	writeData(w, http.StatusOK, balance)
+}
+
+func (h *V2Handler) WithdrawHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	var withdraw models.WithdrawRequest
+	if !decodeJSON(w, r, &withdraw) {
+		return
+	}
+
+	if err := withdraw.Validate(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if !utils.IsValidOrderNum(withdraw.Order) {
+		writeProblemCode(w, r, http.StatusUnprocessableEntity, "invalid_order_number", "invalid order number")
+		return
+	}
+
+	if err := h.BalanceService.WithdrawLoyaltyPoints(r.Context(), userID, withdraw); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// LedgerHandler pages through the ledger, its cursor is the id of the last
+// entry returned.
+func (h *V2Handler) LedgerHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	// the ledger is paged by entry id, only the id of the cursor is used
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var cursor int64
+	if page.After != nil {
+		cursor = page.After.ID
+	}
+
+	statement, err := h.BalanceService.DisplayStatement(r.Context(), userID, cursor, page.Limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	next := pagination{Limit: page.Limit}
+	if statement.NextCursor != 0 {
+		last := statement.Entries[len(statement.Entries)-1]
+		next.NextCursor = models.Cursor{At: last.CreatedAt, ID: statement.NextCursor}.String()
+	}
+	entries := statement.Entries
+	if entries == nil {
+		entries = []models.LedgerEntry{}
+	}
+	writePage(w, entries, next)
+}
+
+func (h *V2Handler) CreateHoldHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	var req models.HoldRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	hold, err := h.HoldsService.CreateHold(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeData(w, http.StatusCreated, hold)
+}
+
+func (h *V2Handler) CaptureHoldHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	holdID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid hold id")
+		return
+	}
+
+	var capture models.WithdrawRequest
+	if !decodeJSON(w, r, &capture) {
+		return
+	}
+
+	if capture.Sum < 0 {
+		writeError(w, r, models.ErrNegativeMoney)
+		return
+	}
+
+	if !utils.IsValidOrderNum(capture.Order) {
+		writeProblemCode(w, r, http.StatusUnprocessableEntity, "invalid_order_number", "invalid order number")
+		return
+	}
+
+	hold, err := h.HoldsService.CaptureHold(r.Context(), userID, holdID, capture)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeData(w, http.StatusOK, hold)
+}
+
+func (h *V2Handler) ReleaseHoldHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	holdID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, "invalid hold id")
+		return
+	}
+
+	hold, err := h.HoldsService.ReleaseHold(r.Context(), userID, holdID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeData(w, http.StatusOK, hold)
+}
+
+func (h *V2Handler) TransferHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	var req models.TransferRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	transfer, err := h.TransfersService.Transfer(r.Context(), userID, req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeData(w, http.StatusCreated, transfer)
+}
+
+func (h *V2Handler) ListTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	page, err := parsePage(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	limit := page.Limit
+	page.Limit++
+	transfers, err := h.TransfersService.ListTransfers(r.Context(), userID, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	transfers, next := cutPage(transfers, limit, func(t models.Transfer) models.Cursor {
+		return models.Cursor{At: t.CreatedAt, ID: t.ID}
+	})
+	if transfers == nil {
+		transfers = []models.Transfer{}
+	}
+	writePage(w, transfers, next)
+}
+
+// ListWithdrawalsHandler takes the filters of the v1 listing but is always
+// paged and JSON only, exports stay with v1.
+func (h *V2Handler) ListWithdrawalsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	filter, err := parseWithdrawalFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultPageLimit
+	}
+
+	limit := filter.Limit
+	filter.Limit++
+	withdrawals := make([]models.Withdrawal, 0, filter.Limit)
+	err = h.BalanceService.StreamWithdrawals(r.Context(), userID, filter, func(wd models.Withdrawal) error {
+		withdrawals = append(withdrawals, wd)
+		return nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	withdrawals, next := cutPage(withdrawals, limit, func(wd models.Withdrawal) models.Cursor {
+		return models.Cursor{At: wd.ProcessedAt, ID: wd.ID}
+	})
+	writePage(w, withdrawals, next)
+}
+
+func (h *V2Handler) ReferralsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	referrals, err := h.ReferralsService.ListReferrals(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeData(w, http.StatusOK, referrals)
+}
+
+func (h *V2Handler) MonthlyStatementHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	month, err := models.ParseStatementMonth(chi.URLParam(r, "month"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	statement, err := h.StatementsService.MonthlyStatement(r.Context(), userID, month)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeData(w, http.StatusOK, statement)
+}
@@ -0,0 +1,350 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/config"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/mocks"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestV2UploadOrderHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrder := mocks.NewMockOrder(ctrl)
+	handler := &V2Handler{OrderService: mockOrder}
+
+	tests := []struct {
+		name           string
+		body           string
+		mockSetup      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "new order",
+			body: `{"number": "12345678903"}`,
+			mockSetup: func() {
+				mockOrder.EXPECT().
+					CreateOrder(gomock.Any(), models.Order{UserID: 1, Number: 12345678903, Status: models.OrderStatusNew}).
+					Return(&service.ResponseInfo{RespStatusCode: http.StatusAccepted}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"data": {"number": "12345678903", "status": "NEW"}}`,
+		},
+		{
+			name: "new order with goods",
+			body: `{"number": "12345678903", "goods": [{"description": "Чайник Bork", "category": "Bork", "price": 7000}]}`,
+			mockSetup: func() {
+				mockOrder.EXPECT().
+					CreateOrder(gomock.Any(), models.Order{
+						UserID: 1,
+						Number: 12345678903,
+						Status: models.OrderStatusNew,
+						Goods:  []models.OrderItem{{Description: "Чайник Bork", Category: "Bork", Price: models.MoneyFromFloat(7000)}},
+					}).
+					Return(&service.ResponseInfo{RespStatusCode: http.StatusAccepted}, nil)
+			},
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"data": {"number": "12345678903", "status": "NEW"}}`,
+		},
+		{
+			name:           "negative price",
+			body:           `{"number": "12345678903", "goods": [{"description": "Ложка", "price": "-1"}]}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "uploaded before",
+			body: `{"number": "12345678903"}`,
+			mockSetup: func() {
+				mockOrder.EXPECT().
+					CreateOrder(gomock.Any(), gomock.Any()).
+					Return(&service.ResponseInfo{RespStatusCode: http.StatusOK}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"data": {"number": "12345678903"}}`,
+		},
+		{
+			name: "uploaded by another user",
+			body: `{"number": "12345678903"}`,
+			mockSetup: func() {
+				mockOrder.EXPECT().
+					CreateOrder(gomock.Any(), gomock.Any()).
+					Return(nil, &service.OrderServiceError{RespStatusCode: http.StatusConflict, ErrMsg: repository.ErrAlreadyExists})
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "invalid luhn",
+			body:           `{"number": "12345678901"}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "number as json number",
+			body:           `{"number": 12345678903}`,
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/user/orders", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req = addUserToContext(req, 1)
+
+			rec := httptest.NewRecorder()
+			handler.UploadOrderHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			} else {
+				assert.Equal(t, problemContentType, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestV2ListOrdersHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrder := mocks.NewMockOrder(ctrl)
+	handler := &V2Handler{OrderService: mockOrder}
+
+	uploaded := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	after := models.Cursor{At: uploaded, ID: 12345678903}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func()
+		expectedStatus int
+		expectedLen    int
+		expectedNext   string
+	}{
+		{
+			name:  "first page has a next one",
+			query: "?limit=2",
+			mockSetup: func() {
+				mockOrder.EXPECT().ListOrders(gomock.Any(), 1, models.Page{Limit: 3}).Return([]models.Order{
+					{Number: 2377225624, CreatedAt: uploaded.Add(time.Hour)},
+					{Number: 12345678903, CreatedAt: uploaded},
+					{Number: 79927398713, CreatedAt: uploaded.Add(-time.Hour)},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    2,
+			expectedNext:   after.String(),
+		},
+		{
+			name:  "last page",
+			query: "?limit=2&cursor=" + after.String(),
+			mockSetup: func() {
+				mockOrder.EXPECT().ListOrders(gomock.Any(), 1, models.Page{After: &after, Limit: 3}).Return([]models.Order{
+					{Number: 79927398713, CreatedAt: uploaded.Add(-time.Hour)},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedLen:    1,
+		},
+		{
+			name: "no orders is an empty page",
+			mockSetup: func() {
+				mockOrder.EXPECT().ListOrders(gomock.Any(), 1, models.Page{Limit: defaultPageLimit + 1}).Return(nil, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "malformed cursor",
+			query:          "?cursor=nope",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := addUserToContext(httptest.NewRequest(http.MethodGet, "/api/v2/user/orders"+tt.query, nil), 1)
+
+			rec := httptest.NewRecorder()
+			handler.ListOrdersHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Data       []json.RawMessage `json:"data"`
+				Pagination pagination        `json:"pagination"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.NotNil(t, body.Data, "an empty page is an empty array")
+			assert.Len(t, body.Data, tt.expectedLen)
+			assert.Equal(t, tt.expectedNext, body.Pagination.NextCursor)
+		})
+	}
+}
+
+func TestV1Deprecation(t *testing.T) {
+	handler := NewHandler(&config.Config{}, &service.Service{}, newTestOpenAPIHandler(t))
+	router := handler.InitAPIRoutes()
+
+	tests := []struct {
+		path       string
+		deprecated bool
+	}{
+		{path: "/api/user/balance", deprecated: true},
+		{path: "/api/v1/user/balance", deprecated: true},
+		{path: "/api/v2/user/balance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			if tt.deprecated {
+				assert.Equal(t, fmt.Sprintf("@%d", v1DeprecatedAt.Unix()), rec.Header().Get("Deprecation"))
+				assert.Equal(t, "Mon, 19 Apr 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+				assert.Equal(t, `</api/v2/user>; rel="successor-version"`, rec.Header().Get("Link"))
+			} else {
+				assert.Empty(t, rec.Header().Get("Deprecation"))
+				assert.Empty(t, rec.Header().Get("Sunset"))
+				assert.Empty(t, rec.Header().Get("Link"))
+			}
+		})
+	}
+}
+
+// v1 keeps the order shape it had before accruals were broken down
+func TestOrderAccrualBreakdownOnlyInV2(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	order := models.Order{
+		Number:      79927398713,
+		Status:      models.OrderStatusProcessed,
+		Accrual:     60000,
+		BaseAccrual: 50000,
+		Multiplier:  1.2,
+		Bonus:       10000,
+	}
+	mockOrder := mocks.NewMockOrder(ctrl)
+	mockOrder.EXPECT().ListOrders(gomock.Any(), 1, gomock.Any()).Return([]models.Order{order}, nil).Times(2)
+
+	rec := httptest.NewRecorder()
+	NewOrderHandler(mockOrder).UserOrdersHandler(rec, addUserToContext(httptest.NewRequest(http.MethodGet, "/api/user/orders", nil), 1))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var v1 []map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&v1))
+	require.Len(t, v1, 1)
+	for _, field := range []string{"base_accrual", "multiplier", "bonus"} {
+		assert.NotContains(t, v1[0], field)
+	}
+	assert.Equal(t, "79927398713", v1[0]["number"])
+
+	rec = httptest.NewRecorder()
+	(&V2Handler{OrderService: mockOrder}).ListOrdersHandler(rec, addUserToContext(httptest.NewRequest(http.MethodGet, "/api/v2/user/orders", nil), 1))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var v2 struct {
+		Data []map[string]any `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&v2))
+	require.Len(t, v2.Data, 1)
+	assert.Equal(t, "79927398713", v2.Data[0]["number"])
+	assert.Equal(t, 500.0, v2.Data[0]["base_accrual"])
+	assert.Equal(t, 1.2, v2.Data[0]["multiplier"])
+	assert.Equal(t, 100.0, v2.Data[0]["bonus"])
+}
+
+func TestV2LedgerHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBalance := mocks.NewMockBalance(ctrl)
+	handler := &V2Handler{BalanceService: mockBalance}
+
+	created := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	after := models.Cursor{At: created, ID: 41}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func()
+		expectedStatus int
+		expectedNext   string
+	}{
+		{
+			name:  "first page has a next one",
+			query: "?limit=2",
+			mockSetup: func() {
+				mockBalance.EXPECT().DisplayStatement(gomock.Any(), 1, int64(0), 2).Return(models.Statement{
+					Entries: []models.LedgerEntry{
+						{ID: 42, CreatedAt: created.Add(time.Hour)},
+						{ID: 41, CreatedAt: created},
+					},
+					NextCursor: 41,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedNext:   after.String(),
+		},
+		{
+			name:  "next page",
+			query: "?limit=2&cursor=" + after.String(),
+			mockSetup: func() {
+				mockBalance.EXPECT().DisplayStatement(gomock.Any(), 1, int64(41), 2).Return(models.Statement{
+					Entries: []models.LedgerEntry{{ID: 40, CreatedAt: created.Add(-time.Hour)}},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "raw entry id is not a cursor",
+			query:          "?cursor=41",
+			mockSetup:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+			req := addUserToContext(httptest.NewRequest(http.MethodGet, "/api/v2/user/balance/ledger"+tt.query, nil), 1)
+
+			rec := httptest.NewRecorder()
+			handler.LedgerHandler(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Pagination pagination `json:"pagination"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, tt.expectedNext, body.Pagination.NextCursor)
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+const (
+	contentTypeJSON   = "application/json"
+	contentTypeNDJSON = "application/x-ndjson"
+	contentTypeCSV    = "text/csv"
+
+	maxWithdrawalsLimit = 500
+	// streamed exports are flushed to the client every flushEvery rows
+	flushEvery = 100
+)
+
+// DisplayUserWithdrawalsHandler lists withdrawals as JSON, NDJSON or CSV
+// depending on the Accept header. Without limit every matching withdrawal is
+// streamed, with limit a page is returned along with a Link to the next one.
+func (h *BalanceHandler) DisplayUserWithdrawalsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserID(r)
+	if !ok {
+		writeProblem(w, r, http.StatusUnauthorized, "user id is missing in context")
+		return
+	}
+
+	format := negotiateContentType(r.Header.Get("Accept"), contentTypeJSON, contentTypeNDJSON, contentTypeCSV)
+	if format == "" {
+		writeProblem(w, r, http.StatusNotAcceptable, "unsupported accept header")
+		return
+	}
+
+	filter, err := parseWithdrawalFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	stream := newWithdrawalsStream(w, format)
+
+	if filter.Limit == 0 {
+		if err := h.BalanceService.StreamWithdrawals(ctx, userID, filter, stream.Write); err != nil {
+			if stream.Started() {
+				logger.Log.Sugar().Errorf("withdrawals export interrupted: %v", err)
+				return
+			}
+			writeError(w, r, err)
+			return
+		}
+		stream.Close()
+		return
+	}
+
+	// one extra row tells whether there is a next page
+	limit := filter.Limit
+	filter.Limit++
+	page := make([]models.Withdrawal, 0, filter.Limit)
+	err = h.BalanceService.StreamWithdrawals(ctx, userID, filter, func(wd models.Withdrawal) error {
+		page = append(page, wd)
+		return nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if len(page) > limit {
+		page = page[:limit]
+		last := page[limit-1]
+		next := models.Cursor{At: last.ProcessedAt, ID: last.ID}
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, nextPageURL(r.URL, next)))
+	}
+
+	for _, wd := range page {
+		if err := stream.Write(wd); err != nil {
+			logger.Log.Sugar().Errorf("failed to write withdrawals: %v", err)
+			return
+		}
+	}
+	stream.Close()
+}
+
+func nextPageURL(u *url.URL, cursor models.Cursor) string {
+	query := u.Query()
+	query.Set("cursor", cursor.String())
+	next := url.URL{Path: u.Path, RawQuery: query.Encode()}
+	return next.String()
+}
+
+// parseWithdrawalFilter reads from, to, min_sum, max_sum, limit and cursor.
+// Dates are RFC 3339 timestamps or plain dates, a plain to date is inclusive.
+func parseWithdrawalFilter(query url.Values) (models.WithdrawalFilter, error) {
+	var filter models.WithdrawalFilter
+	var err error
+
+	if raw := query.Get("from"); raw != "" {
+		if filter.From, _, err = parseFilterTime(raw); err != nil {
+			return filter, fmt.Errorf("%w: malformed from", models.ErrInvalidFilter)
+		}
+	}
+	if raw := query.Get("to"); raw != "" {
+		var dateOnly bool
+		if filter.To, dateOnly, err = parseFilterTime(raw); err != nil {
+			return filter, fmt.Errorf("%w: malformed to", models.ErrInvalidFilter)
+		}
+		if dateOnly {
+			filter.To = filter.To.AddDate(0, 0, 1)
+		}
+	}
+	if raw := query.Get("min_sum"); raw != "" {
+		if filter.MinSum, err = models.ParseMoney(raw); err != nil {
+			return filter, fmt.Errorf("%w: malformed min_sum", models.ErrInvalidFilter)
+		}
+	}
+	if raw := query.Get("max_sum"); raw != "" {
+		if filter.MaxSum, err = models.ParseMoney(raw); err != nil {
+			return filter, fmt.Errorf("%w: malformed max_sum", models.ErrInvalidFilter)
+		}
+	}
+	if raw := query.Get("limit"); raw != "" {
+		if filter.Limit, err = strconv.Atoi(raw); err != nil || filter.Limit < 1 || filter.Limit > maxWithdrawalsLimit {
+			return filter, fmt.Errorf("%w: malformed limit", models.ErrInvalidFilter)
+		}
+	}
+	if raw := query.Get("cursor"); raw != "" {
+		cursor, err := models.ParseCursor(raw)
+		if err != nil {
+			return filter, err
+		}
+		filter.After = &cursor
+	}
+
+	return filter, filter.Validate()
+}
+
+// parseFilterTime takes a date or an RFC 3339 time, the result is in UTC like
+// the timestamps it is compared with.
+func parseFilterTime(raw string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	return t.UTC(), false, err
+}
+
+// negotiateContentType picks the offer preferred by the Accept header, the
+// first offer is used when the header is empty. Empty result means none of
+// the offers is acceptable.
+func negotiateContentType(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && key == "q" {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+
+		for _, offer := range offers {
+			if mediaMatches(mediaType, offer) {
+				best, bestQ = offer, q
+				break
+			}
+		}
+	}
+	return best
+}
+
+func mediaMatches(mediaRange, offer string) bool {
+	if mediaRange == "*/*" || mediaRange == offer {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(offer, prefix+"/")
+}
+
+// withdrawalsStream writes withdrawals as they come, headers are sent with
+// the first row so an empty JSON result can still be answered with 204.
+type withdrawalsStream struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	format  string
+	csv     *csv.Writer
+	written int
+}
+
+func newWithdrawalsStream(w http.ResponseWriter, format string) *withdrawalsStream {
+	return &withdrawalsStream{w: w, rc: http.NewResponseController(w), format: format}
+}
+
+func (s *withdrawalsStream) Started() bool {
+	return s.written > 0
+}
+
+func (s *withdrawalsStream) start() {
+	s.w.Header().Set("Content-Type", s.format)
+	if s.format == contentTypeCSV {
+		s.w.Header().Set("Content-Disposition", `attachment; filename="withdrawals.csv"`)
+	}
+	s.w.WriteHeader(http.StatusOK)
+
+	if s.format == contentTypeCSV {
+		s.csv = csv.NewWriter(s.w)
+		s.csv.Write([]string{"order", "sum", "status", "reversed", "processed_at"})
+	}
+}
+
+func (s *withdrawalsStream) Write(wd models.Withdrawal) error {
+	if s.written == 0 {
+		s.start()
+	}
+
+	var err error
+	switch s.format {
+	case contentTypeCSV:
+		err = s.csv.Write([]string{
+			strconv.FormatInt(wd.Order, 10),
+			wd.Sum.String(),
+			wd.Status,
+			wd.Reversed.String(),
+			wd.ProcessedAt.Format(time.RFC3339),
+		})
+	case contentTypeNDJSON:
+		err = json.NewEncoder(s.w).Encode(wd)
+	default:
+		err = s.writeJSONElement(wd)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.written++
+	if s.written%flushEvery == 0 {
+		return s.flush()
+	}
+	return nil
+}
+
+func (s *withdrawalsStream) writeJSONElement(wd models.Withdrawal) error {
+	data, err := json.Marshal(wd)
+	if err != nil {
+		return err
+	}
+
+	sep := ","
+	if s.written == 0 {
+		sep = "["
+	}
+	if _, err := io.WriteString(s.w, sep); err != nil {
+		return err
+	}
+	_, err = s.w.Write(data)
+	return err
+}
+
+// Close finishes the response, an empty JSON list is answered with 204 while
+// exports are always sent, CSV with its header row.
+func (s *withdrawalsStream) Close() {
+	if s.written == 0 {
+		if s.format == contentTypeJSON {
+			s.w.Header().Set("Content-Type", contentTypeJSON)
+			s.w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		s.start()
+	}
+
+	if s.format == contentTypeJSON {
+		io.WriteString(s.w, "]\n")
+	}
+	if err := s.flush(); err != nil {
+		logger.Log.Sugar().Errorf("failed to flush withdrawals: %v", err)
+	}
+}
+
+func (s *withdrawalsStream) flush() error {
+	if s.csv != nil {
+		s.csv.Flush()
+		if err := s.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
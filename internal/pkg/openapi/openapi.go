@@ -0,0 +1,42 @@
+// Package openapi embeds the OpenAPI document of the user API along with a
+// Swagger UI page rendering it.
+package openapi
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var spec []byte
+
+// SwaggerUI is a page rendering the document served at /api/openapi.json.
+//
+//go:embed swagger.html
+var SwaggerUI []byte
+
+// swaggerUIDist holds swagger-ui-dist 5.18.2, vendored so the docs page does not
+// load scripts from a CDN.
+//
+//go:embed swagger-ui
+var swaggerUIDist embed.FS
+
+// SwaggerUIAssets are the scripts and styles of the Swagger UI page, served
+// under /api/docs/assets/.
+var SwaggerUIAssets, _ = fs.Sub(swaggerUIDist, "swagger-ui")
+
+// Load parses and validates the embedded document.
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse openapi document: %w", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+type AccrualRulesPostgres struct {
+	db *sql.DB
+}
+
+func NewAccrualRulesPostgres(db *sql.DB) *AccrualRulesPostgres {
+	return &AccrualRulesPostgres{db: db}
+}
+
+const getAccrualRules = `SELECT id, type, COALESCE(category, ''), value, COALESCE(cap, 0)
+					FROM accrual_rules WHERE enabled ORDER BY position, id`
+
+func (rp *AccrualRulesPostgres) LoadRules(ctx context.Context) ([]models.AccrualRule, error) {
+	rows, err := rp.db.QueryContext(ctx, getAccrualRules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load accrual rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]models.AccrualRule, 0)
+	for rows.Next() {
+		var rule models.AccrualRule
+		if err := rows.Scan(&rule.ID, &rule.Type, &rule.Category, &rule.Value, &rule.Cap); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+const getOrderItems = `SELECT description, category, price FROM order_items WHERE order_number = $1 ORDER BY id`
+
+func (rp *AccrualRulesPostgres) ListOrderItems(ctx context.Context, orderNumber int64) ([]models.OrderItem, error) {
+	rows, err := rp.db.QueryContext(ctx, getOrderItems, orderNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]models.OrderItem, 0)
+	for rows.Next() {
+		var item models.OrderItem
+		if err := rows.Scan(&item.Description, &item.Category, &item.Price); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
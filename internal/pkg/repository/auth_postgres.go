@@ -2,8 +2,12 @@ package repository
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/base32"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
 	"github.com/jackc/pgerrcode"
@@ -18,23 +22,92 @@ func NewAuthPostgres(db *sql.DB) *AuthPostgres {
 	return &AuthPostgres{db: db}
 }
 
-var ErrUserExists = errors.New("user already exists")
+var (
+	ErrUserExists          = errors.New("user already exists")
+	ErrInvalidReferralCode = errors.New("invalid referral code")
+	ErrWrongPassword       = errors.New("wrong password")
+)
 
-const createUser = `INSERT INTO users (login, password_hash) VALUES ($1, $2) RETURNING id`
+const (
+	createUser = `
+				INSERT INTO users (login, password_hash, referral_code, registered_ip)
+				VALUES ($1, $2, $3, $4) RETURNING id`
 
-func (ap *AuthPostgres) CreateUser(ctx context.Context, user models.User) (int, error) {
-	var userID int
+	getReferrer = `SELECT id, registered_ip FROM users WHERE referral_code = $1`
+
+	// the referee shares an address with the referrer or with another of
+	// the referrer's invites
+	getSameIPReferral = `
+				SELECT EXISTS (
+					SELECT 1 FROM referrals r JOIN users u ON u.id = r.referee_id
+					WHERE r.referrer_id = $1 AND u.registered_ip = $2
+				)`
+
+	insertReferral = `
+				INSERT INTO referrals (referrer_id, referee_id, status, reason, referrer_reward, referee_reward)
+				VALUES ($1, $2, $3, $4, $5, $6)`
+)
+
+// CreateUser registers the user with a referral code of their own. When the
+// user was invited the referral is recorded along with the rewards, which are
+// paid out on the first processed order unless the invite looks fraudulent.
+func (ap *AuthPostgres) CreateUser(ctx context.Context, user models.User, rewards models.ReferralRewards) (int, error) {
+	tx, err := ap.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var referrerID int
+	var referrerIP string
+	if code := normalizeReferralCode(user.ReferralCode); code != "" {
+		err := tx.QueryRowContext(ctx, getReferrer, code).Scan(&referrerID, &referrerIP)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, ErrInvalidReferralCode
+			}
+			return 0, fmt.Errorf("failed to get referrer: %w", err)
+		}
+	}
 
-	row := ap.db.QueryRowContext(ctx, createUser, user.Login, user.Password)
-	err := row.Scan(&userID)
+	code, err := generateReferralCode()
 	if err != nil {
+		return 0, err
+	}
+
+	var userID int
+	row := tx.QueryRowContext(ctx, createUser, user.Login, user.Password, code, user.RegisteredIP)
+	if err := row.Scan(&userID); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
 			return 0, ErrUserExists
 		}
 		return 0, err
 	}
-	return userID, nil
+
+	if referrerID == 0 {
+		return userID, tx.Commit()
+	}
+
+	status, reason := models.ReferralStatusPending, ""
+	if user.RegisteredIP != "" {
+		sameIP := user.RegisteredIP == referrerIP
+		if !sameIP {
+			if err := tx.QueryRowContext(ctx, getSameIPReferral, referrerID, user.RegisteredIP).Scan(&sameIP); err != nil {
+				return 0, fmt.Errorf("failed to check referral ip: %w", err)
+			}
+		}
+		if sameIP {
+			status, reason = models.ReferralStatusRejected, models.ReferralReasonSameIP
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, insertReferral, referrerID, userID, status, reason, rewards.Referrer, rewards.Referee)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert referral: %w", err)
+	}
+
+	return userID, tx.Commit()
 }
 
 const getUser = `SELECT id FROM users WHERE login=$1 AND password_hash=$2`
@@ -47,3 +120,30 @@ func (ap *AuthPostgres) GetUser(ctx context.Context, login, password string) (mo
 
 	return user, err
 }
+
+const updatePassword = `
+				UPDATE users SET password_hash = $3, password_changed_at = NOW()
+				WHERE id = $1 AND password_hash = $2`
+
+func (ap *AuthPostgres) UpdatePassword(ctx context.Context, userID int, oldPassword, newPassword string) error {
+	res, err := ap.db.ExecContext(ctx, updatePassword, userID, oldPassword, newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+func normalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func generateReferralCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate referral code: %w", err)
+	}
+	return base32.StdEncoding.EncodeToString(b), nil
+}
@@ -3,11 +3,14 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 type BalancePostgres struct {
@@ -23,10 +26,12 @@ const insertBalanceIfNotExists = `
 				VALUES ($1, 0, 0)
 				ON CONFLICT (user_id) DO NOTHING;`
 
-const getUserBalance = `SELECT current, withdrawn FROM balance WHERE user_id = $1;`
+const getUserBalance = `SELECT current, current - held, held, withdrawn FROM balance WHERE user_id = $1;`
 
 var ErrNoPoints = errors.New("user has no loyalty points")
 
+const expiringSoonWindow = 30 * 24 * time.Hour
+
 func (bp *BalancePostgres) DisplayUserBalance(ctx context.Context, userID int) (models.Balance, error) {
 	if _, err := bp.db.ExecContext(ctx, insertBalanceIfNotExists, userID); err != nil {
 		return models.Balance{}, fmt.Errorf("failed to insert balance row: %w", err)
@@ -34,24 +39,106 @@ func (bp *BalancePostgres) DisplayUserBalance(ctx context.Context, userID int) (
 
 	var balance models.Balance
 	row := bp.db.QueryRowContext(ctx, getUserBalance, userID)
-	err := row.Scan(&balance.Current, &balance.Withdrawn)
+	err := row.Scan(&balance.Current, &balance.Available, &balance.Held, &balance.Withdrawn)
 	if err != nil {
 		return models.Balance{}, fmt.Errorf("failed to get user balance: %w", err)
 	}
 
+	if balance.ExpiringSoon, err = bp.expiringSoon(ctx, userID, expiringSoonWindow); err != nil {
+		return models.Balance{}, err
+	}
+
 	return balance, nil
 }
 
-const insertWithdrawal = `INSERT INTO withdrawals (user_id, order_id, sum) VALUES ($1, $2, $3)`
+const insertWithdrawal = `INSERT INTO withdrawals (user_id, order_id, sum, status) VALUES ($1, $2, $3, 'COMPLETED')`
 
 const updateBalanceOnWithdraw = `
 				UPDATE balance 
 				SET current = current - $1, withdrawn = withdrawn + $1 
-				WHERE user_id = $2 AND current >= $1`
+				WHERE user_id = $2 AND current - held >= $1
+				RETURNING current`
+
+// getWithdrawalUsage counts withdrawals and sent transfers, both take points
+// out of the balance of the user.
+const getWithdrawalUsage = `
+				SELECT
+					COALESCE((SELECT SUM(sum - reversed) FROM withdrawals
+						WHERE user_id = $1 AND processed_at >= date_trunc('day', LOCALTIMESTAMP)), 0)
+					+ COALESCE((SELECT SUM(sum) FROM transfers
+						WHERE sender_id = $1 AND created_at >= date_trunc('day', LOCALTIMESTAMP)), 0),
+					COALESCE((SELECT SUM(sum - reversed) FROM withdrawals
+						WHERE user_id = $1 AND processed_at >= date_trunc('month', LOCALTIMESTAMP)), 0)
+					+ COALESCE((SELECT SUM(sum) FROM transfers
+						WHERE sender_id = $1 AND created_at >= date_trunc('month', LOCALTIMESTAMP)), 0),
+					(SELECT password_changed_at FROM users WHERE id = $1),
+					LOCALTIMESTAMP`
+
+var (
+	ErrInsufficientBalance = errors.New("insufficient loyalty points")
+	ErrWithdrawalExists    = errors.New("withdrawal for this order already exists")
+)
 
-var ErrInsufficientBalance = errors.New("insufficient loyalty points")
+// registerWithdrawalOrder leaves orders uploaded before, by the user or
+// someone else, as they are.
+const registerWithdrawalOrder = `
+				INSERT INTO orders (user_id, number, status) VALUES ($1, $2, 'NEW')
+				ON CONFLICT (number) DO NOTHING`
+
+// checkWithdrawalLimits applies the limits to sum leaving the balance of the
+// user. For the velocity limits the balance row, which has to exist, is locked
+// so concurrent debits of the user can not slip past them.
+func checkWithdrawalLimits(ctx context.Context, tx *sql.Tx, userID int, sum models.Money, limits models.WithdrawalLimits) error {
+	if err := limits.CheckSum(sum); err != nil {
+		return err
+	}
+	if !limits.VelocityEnabled() {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, lockBalance, userID); err != nil {
+		return fmt.Errorf("failed to lock balance: %w", err)
+	}
 
-func (bp *BalancePostgres) WithdrawLoyaltyPoints(ctx context.Context, userID int, withdraw models.WithdrawRequest) error {
+	var usage models.WithdrawalUsage
+	var changedAt sql.NullTime
+	var now time.Time
+	err := tx.QueryRowContext(ctx, getWithdrawalUsage, userID).Scan(&usage.Today, &usage.ThisMonth, &changedAt, &now)
+	if err != nil {
+		return fmt.Errorf("failed to get withdrawal usage: %w", err)
+	}
+	if changedAt.Valid {
+		usage.PasswordChangedAt = &changedAt.Time
+	}
+	return limits.CheckUsage(usage, sum, now)
+}
+
+// applyWithdrawalAccrual registers the order a withdrawal pays for and
+// enqueues it for accrual when the policy asks for it.
+func applyWithdrawalAccrual(ctx context.Context, tx *sql.Tx, userID int, order int64,
+	accrual models.WithdrawalAccrualPolicy) error {
+	if accrual != models.WithdrawalAccrualRegister {
+		return nil
+	}
+
+	res, err := tx.ExecContext(ctx, registerWithdrawalOrder, userID, order)
+	if err != nil {
+		return fmt.Errorf("failed to register order: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		if _, err := tx.ExecContext(ctx, enqueueOrder, order); err != nil {
+			return fmt.Errorf("failed to enqueue order: %w", err)
+		}
+	}
+	return nil
+}
+
+// WithdrawLoyaltyPoints applies the velocity limits with the balance row
+// locked, concurrent withdrawals of the user can not slip past them. With the
+// register policy the order is registered and enqueued for accrual in the same
+// transaction.
+func (bp *BalancePostgres) WithdrawLoyaltyPoints(ctx context.Context, userID int, withdraw models.WithdrawRequest,
+	limits models.WithdrawalLimits, accrual models.WithdrawalAccrualPolicy) error {
 	tx, err := bp.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -62,60 +149,218 @@ func (bp *BalancePostgres) WithdrawLoyaltyPoints(ctx context.Context, userID int
 		return fmt.Errorf("failed to insert balance row: %w", err)
 	}
 
-	res, err := tx.ExecContext(ctx, updateBalanceOnWithdraw, withdraw.Sum, userID)
-	if err != nil {
-		return fmt.Errorf("failed to update balance: %w", err)
+	if err := checkWithdrawalLimits(ctx, tx, userID, withdraw.Sum, limits); err != nil {
+		return err
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	var current models.Money
+	err = tx.QueryRowContext(ctx, updateBalanceOnWithdraw, withdraw.Sum, userID).Scan(&current)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInsufficientBalance
+		}
+		return fmt.Errorf("failed to update balance: %w", err)
 	}
 
-	if rowsAffected == 0 {
-		return ErrInsufficientBalance
+	if _, err := tx.ExecContext(ctx, consumePointLots, userID, withdraw.Sum); err != nil {
+		return fmt.Errorf("failed to consume point lots: %w", err)
 	}
 
 	_, err = tx.ExecContext(ctx, insertWithdrawal, userID, withdraw.Order, withdraw.Sum)
 	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrWithdrawalExists
+		}
 		return fmt.Errorf("failed to insert withdrawal: %w", err)
 	}
 
+	_, err = tx.ExecContext(ctx, postLedgerEntry, userID, models.LedgerEntryWithdrawal,
+		-withdraw.Sum, current, withdraw.Order, SystemRedemptionAccount)
+	if err != nil {
+		return fmt.Errorf("failed to post ledger entry: %w", err)
+	}
+
+	if err := applyWithdrawalAccrual(ctx, tx, userID, withdraw.Order, accrual); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-const getWithdrawals = `SELECT order_id, sum, processed_at 
-					FROM withdrawals WHERE user_id = $1 ORDER BY processed_at DESC`
+// getWithdrawals arguments: user id, from, to, min sum, max sum, cursor
+// processed_at and id, limit. Reversals are aggregated per withdrawal so rows
+// can be handed out as they are read.
+const getWithdrawals = `
+				SELECT w.id, w.order_id, w.sum, w.status, w.reversed, w.processed_at, rv.reversals
+				FROM withdrawals w
+				LEFT JOIN LATERAL (
+					SELECT json_agg(json_build_object(
+						'sum', r.sum,
+						'reason', r.reason,
+						'created_at', to_char(r.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+					) ORDER BY r.id) AS reversals
+					FROM withdrawal_reversals r WHERE r.withdrawal_id = w.id
+				) rv ON TRUE
+				WHERE w.user_id = $1
+					AND ($2::TIMESTAMP IS NULL OR w.processed_at >= $2)
+					AND ($3::TIMESTAMP IS NULL OR w.processed_at < $3)
+					AND ($4::NUMERIC = 0 OR w.sum >= $4)
+					AND ($5::NUMERIC = 0 OR w.sum <= $5)
+					AND ($6::TIMESTAMP IS NULL OR (w.processed_at, w.id) < ($6, $7::INTEGER))
+				ORDER BY w.processed_at DESC, w.id DESC
+				LIMIT NULLIF($8::INTEGER, 0)`
 
 var ErrNoWithdrawals = errors.New("user has no withdrawals")
 
-func (bp *BalancePostgres) DisplayWithdrawals(ctx context.Context, userID int) ([]models.Withdrawal, error) {
-	rows, err := bp.db.QueryContext(ctx, getWithdrawals, userID)
+// StreamWithdrawals calls fn for every withdrawal of the user matching the
+// filter, newest first, without loading them all into memory. An error
+// returned by fn stops the iteration and is returned as is.
+func (bp *BalancePostgres) StreamWithdrawals(ctx context.Context, userID int, filter models.WithdrawalFilter, fn func(models.Withdrawal) error) error {
+	var cursorAt, cursorID any
+	if filter.After != nil {
+		cursorAt, cursorID = filter.After.At, filter.After.ID
+	}
+
+	rows, err := bp.db.QueryContext(ctx, getWithdrawals, userID, nullTime(filter.From), nullTime(filter.To),
+		filter.MinSum, filter.MaxSum, cursorAt, cursorID, filter.Limit)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to get withdrawals: %w", err)
 	}
 	defer rows.Close()
 
-	withdrawals := make([]models.Withdrawal, 0)
 	for rows.Next() {
 		var w models.Withdrawal
-		timeStr := new(string)
-		if err := rows.Scan(&w.Order, &w.Sum, timeStr); err != nil {
-			return nil, err
+		var reversals []byte
+		if err := rows.Scan(&w.ID, &w.Order, &w.Sum, &w.Status, &w.Reversed, &w.ProcessedAt, &reversals); err != nil {
+			return err
 		}
-		if w.ProcessedAt, err = time.Parse(time.RFC3339, *timeStr); err != nil {
-			return nil, err
+		if reversals != nil {
+			if err := json.Unmarshal(reversals, &w.Reversals); err != nil {
+				return fmt.Errorf("failed to decode reversals: %w", err)
+			}
+		}
+		if err := fn(w); err != nil {
+			return err
 		}
-		withdrawals = append(withdrawals, w)
 	}
+	return rows.Err()
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, err
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+const (
+	lockWithdrawal = `
+				SELECT id, user_id, sum, reversed, status, processed_at
+				FROM withdrawals WHERE order_id = $1 FOR UPDATE`
+
+	updateWithdrawalOnReversal = `
+				UPDATE withdrawals
+				SET reversed = reversed + $2,
+					status = CASE WHEN reversed + $2 = sum THEN 'REVERSED' ELSE status END
+				WHERE id = $1
+				RETURNING reversed, status`
+
+	insertReversal = `INSERT INTO withdrawal_reversals (withdrawal_id, sum, reason) VALUES ($1, $2, $3)`
+
+	updateBalanceOnReversal = `
+				UPDATE balance
+				SET current = current + $1, withdrawn = withdrawn - $1, updated_at = NOW()
+				WHERE user_id = $2
+				RETURNING current`
+
+	getWithdrawalReversals = `
+				SELECT sum, reason, created_at FROM withdrawal_reversals
+				WHERE withdrawal_id = $1 ORDER BY id`
+)
+
+var (
+	ErrWithdrawalNotFound        = errors.New("withdrawal not found")
+	ErrWithdrawalNotReversible   = errors.New("withdrawal can not be reversed in its current state")
+	ErrReversalExceedsWithdrawal = errors.New("reversal sum exceeds the withdrawn points left")
+)
+
+// ReverseWithdrawal credits reversal.Sum of the withdrawal made for the order
+// back to the user, zero sum reverses the rest of the withdrawal. The
+// withdrawal becomes REVERSED once all of its points are returned.
+func (bp *BalancePostgres) ReverseWithdrawal(ctx context.Context, order int64, reversal models.ReversalRequest) (models.Withdrawal, error) {
+	tx, err := bp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Withdrawal{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id, userID int
+	w := models.Withdrawal{Order: order}
+	err = tx.QueryRowContext(ctx, lockWithdrawal, order).
+		Scan(&id, &userID, &w.Sum, &w.Reversed, &w.Status, &w.ProcessedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Withdrawal{}, ErrWithdrawalNotFound
+		}
+		return models.Withdrawal{}, fmt.Errorf("failed to lock withdrawal: %w", err)
+	}
+
+	if w.Status != models.WithdrawalStatusCompleted {
+		return models.Withdrawal{}, ErrWithdrawalNotReversible
+	}
+
+	left := w.Sum - w.Reversed
+	sum := reversal.Sum
+	if sum == 0 {
+		sum = left
+	}
+	if sum > left {
+		return models.Withdrawal{}, ErrReversalExceedsWithdrawal
 	}
 
-	if len(withdrawals) == 0 {
-		return []models.Withdrawal{}, nil
+	err = tx.QueryRowContext(ctx, updateWithdrawalOnReversal, id, sum).Scan(&w.Reversed, &w.Status)
+	if err != nil {
+		return models.Withdrawal{}, fmt.Errorf("failed to update withdrawal: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, insertReversal, id, sum, reversal.Reason); err != nil {
+		return models.Withdrawal{}, fmt.Errorf("failed to insert reversal: %w", err)
+	}
+
+	var current models.Money
+	if err := tx.QueryRowContext(ctx, updateBalanceOnReversal, sum, userID).Scan(&current); err != nil {
+		return models.Withdrawal{}, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	// returned points start a new lot
+	if _, err := tx.ExecContext(ctx, addPointLot, userID, order, sum); err != nil {
+		return models.Withdrawal{}, fmt.Errorf("failed to add point lot: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, postLedgerEntry, userID, models.LedgerEntryReversal,
+		sum, current, order, SystemRedemptionAccount)
+	if err != nil {
+		return models.Withdrawal{}, fmt.Errorf("failed to post ledger entry: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, getWithdrawalReversals, id)
+	if err != nil {
+		return models.Withdrawal{}, fmt.Errorf("failed to get reversals: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.WithdrawalReversal
+		if err := rows.Scan(&r.Sum, &r.Reason, &r.CreatedAt); err != nil {
+			return models.Withdrawal{}, err
+		}
+		w.Reversals = append(w.Reversals, r)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Withdrawal{}, err
 	}
+	rows.Close()
 
-	return withdrawals, nil
+	return w, tx.Commit()
 }
@@ -0,0 +1,373 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const SystemCampaignAccount = "system:campaign"
+
+type CampaignsPostgres struct {
+	db *sql.DB
+}
+
+func NewCampaignsPostgres(db *sql.DB) *CampaignsPostgres {
+	return &CampaignsPostgres{db: db}
+}
+
+var (
+	ErrCampaignNotFound     = errors.New("campaign not found")
+	ErrBonusNotFound        = errors.New("campaign bonus not found")
+	ErrBonusAlreadyReversed = errors.New("campaign bonus is already reversed")
+)
+
+const campaignColumns = `id, name, type, multiplier, points, eligibility, budget, spent, starts_at, ends_at, active`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCampaign(row rowScanner) (models.Campaign, error) {
+	var c models.Campaign
+	var eligibility []byte
+	err := row.Scan(&c.ID, &c.Name, &c.Type, &c.Multiplier, &c.Points, &eligibility,
+		&c.Budget, &c.Spent, &c.StartsAt, &c.EndsAt, &c.Active)
+	if err != nil {
+		return models.Campaign{}, err
+	}
+
+	if err := json.Unmarshal(eligibility, &c.Eligibility); err != nil {
+		return models.Campaign{}, fmt.Errorf("failed to decode campaign eligibility: %w", err)
+	}
+	return c, nil
+}
+
+const (
+	insertCampaign = `
+				INSERT INTO campaigns (name, type, multiplier, points, eligibility, budget, starts_at, ends_at, active)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, COALESCE($9, TRUE))
+				RETURNING ` + campaignColumns
+
+	updateCampaign = `
+				UPDATE campaigns
+				SET name = $2, type = $3, multiplier = $4, points = $5, eligibility = $6,
+					budget = $7, starts_at = $8, ends_at = $9, active = COALESCE($10, active)
+				WHERE id = $1
+				RETURNING ` + campaignColumns
+
+	getCampaign = `SELECT ` + campaignColumns + ` FROM campaigns WHERE id = $1`
+
+	getCampaigns = `SELECT ` + campaignColumns + ` FROM campaigns ORDER BY starts_at DESC, id DESC`
+
+	deactivateCampaign = `UPDATE campaigns SET active = FALSE WHERE id = $1`
+)
+
+// CreateCampaign creates an active campaign unless c.Active says otherwise.
+func (cp *CampaignsPostgres) CreateCampaign(ctx context.Context, c models.Campaign) (models.Campaign, error) {
+	eligibility, err := json.Marshal(c.Eligibility)
+	if err != nil {
+		return models.Campaign{}, err
+	}
+
+	row := cp.db.QueryRowContext(ctx, insertCampaign, c.Name, c.Type, c.Multiplier, c.Points, eligibility,
+		c.Budget, c.StartsAt, c.EndsAt, c.Active)
+	created, err := scanCampaign(row)
+	if err != nil {
+		return models.Campaign{}, fmt.Errorf("failed to insert campaign: %w", err)
+	}
+	return created, nil
+}
+
+// UpdateCampaign replaces the campaign definition, spent budget is kept and so
+// is the active flag when c.Active is nil.
+func (cp *CampaignsPostgres) UpdateCampaign(ctx context.Context, c models.Campaign) (models.Campaign, error) {
+	eligibility, err := json.Marshal(c.Eligibility)
+	if err != nil {
+		return models.Campaign{}, err
+	}
+
+	row := cp.db.QueryRowContext(ctx, updateCampaign, c.ID, c.Name, c.Type, c.Multiplier, c.Points, eligibility,
+		c.Budget, c.StartsAt, c.EndsAt, c.Active)
+	updated, err := scanCampaign(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Campaign{}, ErrCampaignNotFound
+		}
+		return models.Campaign{}, fmt.Errorf("failed to update campaign: %w", err)
+	}
+	return updated, nil
+}
+
+func (cp *CampaignsPostgres) GetCampaign(ctx context.Context, id int) (models.Campaign, error) {
+	c, err := scanCampaign(cp.db.QueryRowContext(ctx, getCampaign, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Campaign{}, ErrCampaignNotFound
+		}
+		return models.Campaign{}, fmt.Errorf("failed to get campaign: %w", err)
+	}
+	return c, nil
+}
+
+func (cp *CampaignsPostgres) ListCampaigns(ctx context.Context) ([]models.Campaign, error) {
+	rows, err := cp.db.QueryContext(ctx, getCampaigns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	campaigns := make([]models.Campaign, 0)
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, c)
+	}
+	return campaigns, rows.Err()
+}
+
+// DeleteCampaign only deactivates the campaign, its bonuses stay reportable.
+func (cp *CampaignsPostgres) DeleteCampaign(ctx context.Context, id int) error {
+	res, err := cp.db.ExecContext(ctx, deactivateCampaign, id)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate campaign: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrCampaignNotFound
+	}
+	return nil
+}
+
+const (
+	bonusColumns = `id, campaign_id, user_id, order_number, amount, status, created_at, reversed_at`
+
+	getCampaignBonuses = `SELECT ` + bonusColumns + ` FROM campaign_bonuses WHERE campaign_id = $1 ORDER BY id DESC`
+)
+
+func scanBonus(row rowScanner) (models.CampaignBonus, error) {
+	var b models.CampaignBonus
+	var reversedAt sql.NullTime
+	err := row.Scan(&b.ID, &b.CampaignID, &b.UserID, &b.Order, &b.Amount, &b.Status, &b.CreatedAt, &reversedAt)
+	if reversedAt.Valid {
+		b.ReversedAt = &reversedAt.Time
+	}
+	return b, err
+}
+
+func (cp *CampaignsPostgres) ListBonuses(ctx context.Context, campaignID int) ([]models.CampaignBonus, error) {
+	rows, err := cp.db.QueryContext(ctx, getCampaignBonuses, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get campaign bonuses: %w", err)
+	}
+	defer rows.Close()
+
+	bonuses := make([]models.CampaignBonus, 0)
+	for rows.Next() {
+		b, err := scanBonus(rows)
+		if err != nil {
+			return nil, err
+		}
+		bonuses = append(bonuses, b)
+	}
+	return bonuses, rows.Err()
+}
+
+const (
+	lockBonus = `SELECT ` + bonusColumns + ` FROM campaign_bonuses WHERE id = $1 FOR UPDATE`
+
+	markBonusReversed = `
+				UPDATE campaign_bonuses SET status = 'REVERSED', reversed_at = NOW()
+				WHERE id = $1
+				RETURNING reversed_at`
+
+	refundCampaignBudget = `UPDATE campaigns SET spent = spent - $2 WHERE id = $1`
+
+	debitBalanceOnBonusReversal = `
+				UPDATE balance SET current = current - $1, updated_at = NOW()
+				WHERE user_id = $2 AND current - held >= $1
+				RETURNING current`
+)
+
+// ReverseBonus takes a credited bonus back from the user and returns it to
+// the campaign budget, the base accrual of the order is not touched.
+func (cp *CampaignsPostgres) ReverseBonus(ctx context.Context, bonusID int64) (models.CampaignBonus, error) {
+	tx, err := cp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.CampaignBonus{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	bonus, err := scanBonus(tx.QueryRowContext(ctx, lockBonus, bonusID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.CampaignBonus{}, ErrBonusNotFound
+		}
+		return models.CampaignBonus{}, fmt.Errorf("failed to lock bonus: %w", err)
+	}
+	if bonus.Status != models.BonusStatusCredited {
+		return models.CampaignBonus{}, ErrBonusAlreadyReversed
+	}
+
+	var current models.Money
+	err = tx.QueryRowContext(ctx, debitBalanceOnBonusReversal, bonus.Amount, bonus.UserID).Scan(&current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.CampaignBonus{}, ErrInsufficientBalance
+		}
+		return models.CampaignBonus{}, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, consumePointLots, bonus.UserID, bonus.Amount); err != nil {
+		return models.CampaignBonus{}, fmt.Errorf("failed to consume point lots: %w", err)
+	}
+
+	var reversedAt sql.NullTime
+	if err := tx.QueryRowContext(ctx, markBonusReversed, bonusID).Scan(&reversedAt); err != nil {
+		return models.CampaignBonus{}, fmt.Errorf("failed to update bonus: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, refundCampaignBudget, bonus.CampaignID, bonus.Amount); err != nil {
+		return models.CampaignBonus{}, fmt.Errorf("failed to update campaign budget: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, postLedgerEntry, bonus.UserID, models.LedgerEntryBonusReversal,
+		-bonus.Amount, current, bonus.Order, SystemCampaignAccount)
+	if err != nil {
+		return models.CampaignBonus{}, fmt.Errorf("failed to post ledger entry: %w", err)
+	}
+
+	bonus.Status = models.BonusStatusReversed
+	bonus.ReversedAt = &reversedAt.Time
+	return bonus, tx.Commit()
+}
+
+const (
+	getRunningCampaigns = `
+				SELECT ` + campaignColumns + ` FROM campaigns
+				WHERE active AND starts_at <= NOW() AND ends_at > NOW()
+					AND (budget = 0 OR spent < budget)
+				ORDER BY id`
+
+	getCampaignOrderInfo = `
+				SELECT
+					NOT EXISTS (SELECT 1 FROM orders WHERE user_id = $1 AND status = 'PROCESSED' AND number <> $2),
+					COALESCE((SELECT tier FROM user_tiers WHERE user_id = $1), '')`
+
+	insertCampaignBonus = `
+				INSERT INTO campaign_bonuses (campaign_id, user_id, order_number, amount)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (campaign_id, order_number) DO NOTHING`
+
+	spendCampaignBudget = `
+				UPDATE campaigns SET spent = spent + $2
+				WHERE id = $1 AND (budget = 0 OR spent + $2 <= budget)`
+
+	lockCampaignBudget = `SELECT GREATEST(budget - spent, 0) FROM campaigns WHERE id = $1 FOR UPDATE`
+
+	creditBalanceOnBonus = `
+				UPDATE balance SET current = current + $1, updated_at = NOW()
+				WHERE user_id = $2
+				RETURNING current`
+)
+
+// applyCampaignBonuses credits the bonuses of running campaigns for an order
+// that has just been processed, within the transaction that credited the
+// accrual.
+func applyCampaignBonuses(ctx context.Context, tx pgx.Tx, userID int, orderNumber int64, accrual models.Money) error {
+	rows, err := tx.Query(ctx, getRunningCampaigns)
+	if err != nil {
+		return fmt.Errorf("failed to get running campaigns: %w", err)
+	}
+	campaigns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Campaign, error) {
+		return scanCampaign(row)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get running campaigns: %w", err)
+	}
+	if len(campaigns) == 0 {
+		return nil
+	}
+
+	order := models.CampaignOrder{Accrual: accrual}
+	if err := tx.QueryRow(ctx, getCampaignOrderInfo, userID, orderNumber).Scan(&order.FirstOrder, &order.Tier); err != nil {
+		return fmt.Errorf("failed to get order info for campaigns: %w", err)
+	}
+
+	for _, c := range campaigns {
+		bonus := c.Bonus(order)
+		if bonus <= 0 {
+			continue
+		}
+
+		bonus, err := spendBudget(ctx, tx, c.ID, bonus)
+		if err != nil {
+			return err
+		}
+		if bonus <= 0 {
+			continue
+		}
+
+		tag, err := tx.Exec(ctx, insertCampaignBonus, c.ID, userID, orderNumber, bonus)
+		if err != nil {
+			return fmt.Errorf("failed to insert campaign bonus: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			if _, err := tx.Exec(ctx, refundCampaignBudget, c.ID, bonus); err != nil {
+				return fmt.Errorf("failed to update campaign budget: %w", err)
+			}
+			continue
+		}
+
+		var current models.Money
+		if err := tx.QueryRow(ctx, creditBalanceOnBonus, bonus, userID).Scan(&current); err != nil {
+			return fmt.Errorf("failed to credit bonus: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, addPointLot, userID, orderNumber, bonus); err != nil {
+			return fmt.Errorf("failed to add point lot: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, postLedgerEntry, userID, models.LedgerEntryBonus,
+			bonus, current, orderNumber, SystemCampaignAccount)
+		if err != nil {
+			return fmt.Errorf("failed to post ledger entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// spendBudget takes up to amount from the budget of the campaign and returns
+// the part taken. Bonuses that fit the budget are spent atomically, only a
+// campaign about to run out of budget is locked to hand out the rest.
+func spendBudget(ctx context.Context, tx pgx.Tx, campaignID int, amount models.Money) (models.Money, error) {
+	tag, err := tx.Exec(ctx, spendCampaignBudget, campaignID, amount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update campaign budget: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return amount, nil
+	}
+
+	var remaining models.Money
+	if err := tx.QueryRow(ctx, lockCampaignBudget, campaignID).Scan(&remaining); err != nil {
+		return 0, fmt.Errorf("failed to lock campaign budget: %w", err)
+	}
+
+	amount = min(amount, remaining)
+	if amount <= 0 {
+		return 0, nil
+	}
+	if _, err := tx.Exec(ctx, spendCampaignBudget, campaignID, amount); err != nil {
+		return 0, fmt.Errorf("failed to update campaign budget: %w", err)
+	}
+	return amount, nil
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type HoldsPostgres struct {
+	db *sql.DB
+}
+
+func NewHoldsPostgres(db *sql.DB) *HoldsPostgres {
+	return &HoldsPostgres{db: db}
+}
+
+var (
+	ErrHoldNotFound       = errors.New("hold not found")
+	ErrHoldNotActive      = errors.New("hold is already captured, released or expired")
+	ErrCaptureExceedsHold = errors.New("capture sum exceeds the held points")
+)
+
+const (
+	reserveBalance = `
+				UPDATE balance SET held = held + $1, updated_at = NOW()
+				WHERE user_id = $2 AND current - held >= $1`
+
+	insertHold = `
+				INSERT INTO point_holds (user_id, sum, expires_at)
+				VALUES ($1, $2, $3)
+				RETURNING id, status, created_at, expires_at`
+)
+
+func (hp *HoldsPostgres) CreateHold(ctx context.Context, userID int, sum models.Money, ttl time.Duration) (models.Hold, error) {
+	tx, err := hp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Hold{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, insertBalanceIfNotExists, userID); err != nil {
+		return models.Hold{}, fmt.Errorf("failed to insert balance row: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, reserveBalance, sum, userID)
+	if err != nil {
+		return models.Hold{}, fmt.Errorf("failed to reserve points: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return models.Hold{}, err
+	} else if affected == 0 {
+		return models.Hold{}, ErrInsufficientBalance
+	}
+
+	hold := models.Hold{Sum: sum}
+	err = tx.QueryRowContext(ctx, insertHold, userID, sum, time.Now().Add(ttl)).
+		Scan(&hold.ID, &hold.Status, &hold.CreatedAt, &hold.ExpiresAt)
+	if err != nil {
+		return models.Hold{}, fmt.Errorf("failed to insert hold: %w", err)
+	}
+
+	return hold, tx.Commit()
+}
+
+const (
+	lockHold = `
+				SELECT sum, status, created_at, expires_at, expires_at < NOW()
+				FROM point_holds WHERE id = $1 AND user_id = $2 FOR UPDATE`
+
+	resolveHold = `
+				UPDATE point_holds SET status = $2, order_id = $3, resolved_at = NOW()
+				WHERE id = $1`
+
+	releaseBalance = `
+				UPDATE balance SET held = held - $1, updated_at = NOW()
+				WHERE user_id = $2`
+
+	captureBalance = `
+				UPDATE balance
+				SET held = held - $1, current = current - $2, withdrawn = withdrawn + $2, updated_at = NOW()
+				WHERE user_id = $3
+				RETURNING current`
+)
+
+// lockActiveHold locks the hold of the user, expired holds are treated as not
+// active even when the expirer has not got to them yet.
+func lockActiveHold(ctx context.Context, tx *sql.Tx, userID int, holdID int64) (models.Hold, error) {
+	hold := models.Hold{ID: holdID}
+
+	var expired bool
+	err := tx.QueryRowContext(ctx, lockHold, holdID, userID).
+		Scan(&hold.Sum, &hold.Status, &hold.CreatedAt, &hold.ExpiresAt, &expired)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Hold{}, ErrHoldNotFound
+		}
+		return models.Hold{}, fmt.Errorf("failed to lock hold: %w", err)
+	}
+
+	if hold.Status != models.HoldStatusHeld || expired {
+		return models.Hold{}, ErrHoldNotActive
+	}
+	return hold, nil
+}
+
+// CaptureHold turns the hold into a withdrawal for the order, subject to the
+// same limits and accrual policy as any other withdrawal. Zero sum captures
+// the whole hold, otherwise the rest of the hold is released.
+func (hp *HoldsPostgres) CaptureHold(ctx context.Context, userID int, holdID int64, capture models.WithdrawRequest,
+	limits models.WithdrawalLimits, accrual models.WithdrawalAccrualPolicy) (models.Hold, error) {
+	tx, err := hp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Hold{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := lockActiveHold(ctx, tx, userID, holdID)
+	if err != nil {
+		return models.Hold{}, err
+	}
+
+	sum := capture.Sum
+	if sum == 0 {
+		sum = hold.Sum
+	}
+	if sum > hold.Sum {
+		return models.Hold{}, ErrCaptureExceedsHold
+	}
+	if err := checkWithdrawalLimits(ctx, tx, userID, sum, limits); err != nil {
+		return models.Hold{}, err
+	}
+
+	var current models.Money
+	if err := tx.QueryRowContext(ctx, captureBalance, hold.Sum, sum, userID).Scan(&current); err != nil {
+		return models.Hold{}, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, consumePointLots, userID, sum); err != nil {
+		return models.Hold{}, fmt.Errorf("failed to consume point lots: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, insertWithdrawal, userID, capture.Order, sum)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return models.Hold{}, ErrWithdrawalExists
+		}
+		return models.Hold{}, fmt.Errorf("failed to insert withdrawal: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, postLedgerEntry, userID, models.LedgerEntryWithdrawal,
+		-sum, current, capture.Order, SystemRedemptionAccount)
+	if err != nil {
+		return models.Hold{}, fmt.Errorf("failed to post ledger entry: %w", err)
+	}
+
+	if err := applyWithdrawalAccrual(ctx, tx, userID, capture.Order, accrual); err != nil {
+		return models.Hold{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, resolveHold, holdID, models.HoldStatusCaptured, capture.Order); err != nil {
+		return models.Hold{}, fmt.Errorf("failed to update hold: %w", err)
+	}
+
+	hold.Status = models.HoldStatusCaptured
+	hold.Order = capture.Order
+	return hold, tx.Commit()
+}
+
+func (hp *HoldsPostgres) ReleaseHold(ctx context.Context, userID int, holdID int64) (models.Hold, error) {
+	tx, err := hp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Hold{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	hold, err := lockActiveHold(ctx, tx, userID, holdID)
+	if err != nil {
+		return models.Hold{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, releaseBalance, hold.Sum, userID); err != nil {
+		return models.Hold{}, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, resolveHold, holdID, models.HoldStatusReleased, nil); err != nil {
+		return models.Hold{}, fmt.Errorf("failed to update hold: %w", err)
+	}
+
+	hold.Status = models.HoldStatusReleased
+	return hold, tx.Commit()
+}
+
+// expireHolds releases the points of stale holds, holds locked by a running
+// capture or release are left for the next run.
+const expireHolds = `
+				WITH expired AS (
+					UPDATE point_holds SET status = 'EXPIRED', resolved_at = NOW()
+					WHERE id IN (
+						SELECT id FROM point_holds
+						WHERE status = 'HELD' AND expires_at < NOW()
+						FOR UPDATE SKIP LOCKED
+					)
+					RETURNING user_id, sum
+				), released AS (
+					UPDATE balance b SET held = b.held - e.sum, updated_at = NOW()
+					FROM (SELECT user_id, SUM(sum) AS sum FROM expired GROUP BY user_id) e
+					WHERE b.user_id = e.user_id
+					RETURNING b.user_id
+				)
+				SELECT COUNT(*) FROM expired`
+
+func (hp *HoldsPostgres) ExpireHolds(ctx context.Context) (int64, error) {
+	var expired int64
+	if err := hp.db.QueryRowContext(ctx, expireHolds).Scan(&expired); err != nil {
+		return 0, fmt.Errorf("failed to expire holds: %w", err)
+	}
+	return expired, nil
+}
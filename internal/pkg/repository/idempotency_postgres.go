@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+type IdempotencyPostgres struct {
+	db *sql.DB
+}
+
+func NewIdempotencyPostgres(db *sql.DB) *IdempotencyPostgres {
+	return &IdempotencyPostgres{db: db}
+}
+
+// acquireIdempotencyKey takes over keys whose ttl is over, RETURNING yields
+// no row when the key is held by a live record.
+const (
+	acquireIdempotencyKey = `
+				INSERT INTO idempotency_keys (user_id, key, request_hash, expires_at)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (user_id, key) DO UPDATE
+				SET request_hash = EXCLUDED.request_hash,
+					status_code = NULL,
+					content_type = NULL,
+					response_body = NULL,
+					created_at = NOW(),
+					expires_at = EXCLUDED.expires_at
+				WHERE idempotency_keys.expires_at < NOW()
+				RETURNING key`
+
+	getIdempotencyKey = `
+				SELECT key, request_hash, COALESCE(status_code, 0), COALESCE(content_type, ''),
+					COALESCE(response_body, ''::BYTEA), expires_at
+				FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+)
+
+func (ip *IdempotencyPostgres) AcquireKey(ctx context.Context, userID int, key, requestHash string, ttl time.Duration) (bool, models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+
+	var acquired string
+	err := ip.db.QueryRowContext(ctx, acquireIdempotencyKey, userID, key, requestHash, time.Now().Add(ttl)).Scan(&acquired)
+	if err == nil {
+		return true, record, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, record, fmt.Errorf("failed to acquire idempotency key: %w", err)
+	}
+
+	err = ip.db.QueryRowContext(ctx, getIdempotencyKey, userID, key).
+		Scan(&record.Key, &record.RequestHash, &record.StatusCode, &record.ContentType, &record.Body, &record.ExpiresAt)
+	if err != nil {
+		return false, record, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return false, record, nil
+}
+
+const completeIdempotencyKey = `
+				UPDATE idempotency_keys
+				SET status_code = $3, content_type = $4, response_body = $5
+				WHERE user_id = $1 AND key = $2`
+
+func (ip *IdempotencyPostgres) CompleteKey(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error {
+	if _, err := ip.db.ExecContext(ctx, completeIdempotencyKey, userID, key, statusCode, contentType, body); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+const releaseIdempotencyKey = `DELETE FROM idempotency_keys WHERE user_id = $1 AND key = $2`
+
+func (ip *IdempotencyPostgres) ReleaseKey(ctx context.Context, userID int, key string) error {
+	if _, err := ip.db.ExecContext(ctx, releaseIdempotencyKey, userID, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+const purgeIdempotencyKeys = `DELETE FROM idempotency_keys WHERE expires_at < NOW()`
+
+func (ip *IdempotencyPostgres) PurgeExpiredKeys(ctx context.Context) (int64, error) {
+	res, err := ip.db.ExecContext(ctx, purgeIdempotencyKeys)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+// Every ledger transaction consists of two entries summing up to zero: one on
+// the user account and one on a system account.
+const (
+	SystemAccrualAccount    = "system:accrual"
+	SystemRedemptionAccount = "system:redemption"
+	SystemAdjustmentAccount = "system:adjustment"
+)
+
+// postLedgerEntry arguments: user id, entry type, amount signed for the user,
+// user balance after the entry, order number, system account.
+const postLedgerEntry = `
+				WITH tx AS (SELECT nextval('ledger_transaction_seq') AS id)
+				INSERT INTO ledger_entries (transaction_id, account, user_id, entry_type, amount, balance_after, order_number)
+				SELECT tx.id, 'user:' || $1::INTEGER, $1::INTEGER, $2, $3::NUMERIC, $4::NUMERIC, $5::BIGINT FROM tx
+				UNION ALL
+				SELECT tx.id, $6, NULL, $2, -$3::NUMERIC, NULL, $5::BIGINT FROM tx`
+
+const getStatement = `
+				SELECT id, entry_type, amount, balance_after, COALESCE(order_number, 0), created_at
+				FROM ledger_entries
+				WHERE user_id = $1 AND ($2 = 0 OR id < $2)
+				ORDER BY id DESC LIMIT $3`
+
+func (bp *BalancePostgres) GetStatement(ctx context.Context, userID int, cursor int64, limit int) (models.Statement, error) {
+	rows, err := bp.db.QueryContext(ctx, getStatement, userID, cursor, limit+1)
+	if err != nil {
+		return models.Statement{}, fmt.Errorf("failed to get statement: %w", err)
+	}
+	defer rows.Close()
+
+	statement := models.Statement{Entries: make([]models.LedgerEntry, 0, limit)}
+	for rows.Next() {
+		var e models.LedgerEntry
+		if err := rows.Scan(&e.ID, &e.Type, &e.Amount, &e.BalanceAfter, &e.Order, &e.CreatedAt); err != nil {
+			return models.Statement{}, err
+		}
+		statement.Entries = append(statement.Entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return models.Statement{}, err
+	}
+
+	if len(statement.Entries) > limit {
+		statement.Entries = statement.Entries[:limit]
+		statement.NextCursor = statement.Entries[limit-1].ID
+	}
+	return statement, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+const SystemExpirationAccount = "system:expiration"
+
+// Accrued points are kept in lots that expire after 12 months, the points of
+// a user are spent from the lots closest to expiry first.
+const (
+	addPointLot = `
+				INSERT INTO point_lots (user_id, order_number, amount, remaining, expires_at)
+				VALUES ($1, $2, $3::NUMERIC, $3::NUMERIC, NOW() + INTERVAL '12 months')`
+
+	// consumePointLots arguments: user id, amount to take from the lots.
+	consumePointLots = `
+				WITH locked AS (
+					SELECT id, remaining, expires_at FROM point_lots
+					WHERE user_id = $1 AND remaining > 0
+					ORDER BY expires_at, id
+					FOR UPDATE
+				), ordered AS (
+					SELECT id, remaining,
+						SUM(remaining) OVER (ORDER BY expires_at, id) - remaining AS taken_before
+					FROM locked
+				)
+				UPDATE point_lots p
+				SET remaining = p.remaining - LEAST(o.remaining, $2::NUMERIC - o.taken_before)
+				FROM ordered o
+				WHERE p.id = o.id AND o.taken_before < $2::NUMERIC`
+)
+
+type PointLotsPostgres struct {
+	db *sql.DB
+}
+
+func NewPointLotsPostgres(db *sql.DB) *PointLotsPostgres {
+	return &PointLotsPostgres{db: db}
+}
+
+const getUsersWithExpiredLots = `
+				SELECT DISTINCT user_id FROM point_lots
+				WHERE remaining > 0 AND expires_at <= NOW()
+				ORDER BY user_id`
+
+func (lp *PointLotsPostgres) UsersWithExpiredLots(ctx context.Context) ([]int, error) {
+	rows, err := lp.db.QueryContext(ctx, getUsersWithExpiredLots)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users with expired points: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]int, 0)
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	return users, rows.Err()
+}
+
+const (
+	lockBalanceHeld = `SELECT current, held FROM balance WHERE user_id = $1 FOR UPDATE`
+
+	getExpiredRemaining = `
+				SELECT COALESCE(SUM(remaining), 0) FROM point_lots
+				WHERE user_id = $1 AND remaining > 0 AND expires_at <= NOW()`
+
+	updateBalanceOnExpiration = `
+				UPDATE balance SET current = current - $1, updated_at = NOW()
+				WHERE user_id = $2
+				RETURNING current`
+)
+
+// ExpireUserPoints writes off the points of expired lots of the user. Points
+// reserved by holds are not written off until the holds are resolved.
+func (lp *PointLotsPostgres) ExpireUserPoints(ctx context.Context, userID int) (models.Money, error) {
+	tx, err := lp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current, held models.Money
+	if err := tx.QueryRowContext(ctx, lockBalanceHeld, userID).Scan(&current, &held); err != nil {
+		return 0, fmt.Errorf("failed to lock balance: %w", err)
+	}
+
+	var expired models.Money
+	if err := tx.QueryRowContext(ctx, getExpiredRemaining, userID).Scan(&expired); err != nil {
+		return 0, fmt.Errorf("failed to get expired points: %w", err)
+	}
+
+	expired = min(expired, current-held)
+	if expired <= 0 {
+		return 0, tx.Commit()
+	}
+
+	// expired lots are always the first ones to be consumed
+	if _, err := tx.ExecContext(ctx, consumePointLots, userID, expired); err != nil {
+		return 0, fmt.Errorf("failed to consume expired lots: %w", err)
+	}
+
+	if err := tx.QueryRowContext(ctx, updateBalanceOnExpiration, expired, userID).Scan(&current); err != nil {
+		return 0, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, postLedgerEntry, userID, models.LedgerEntryExpiration,
+		-expired, current, nil, SystemExpirationAccount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to post ledger entry: %w", err)
+	}
+
+	return expired, tx.Commit()
+}
+
+const getExpiringSoon = `
+				SELECT COALESCE(SUM(remaining), 0) FROM point_lots
+				WHERE user_id = $1 AND remaining > 0 AND expires_at <= $2`
+
+func (bp *BalancePostgres) expiringSoon(ctx context.Context, userID int, window time.Duration) (models.Money, error) {
+	var expiring models.Money
+	err := bp.db.QueryRowContext(ctx, getExpiringSoon, userID, time.Now().Add(window)).Scan(&expiring)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expiring points: %w", err)
+	}
+	return expiring, nil
+}
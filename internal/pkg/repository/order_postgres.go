@@ -21,10 +21,26 @@ func NewOrderPostgres(db *sql.DB) *OrderPostgres {
 
 var ErrAlreadyPostedByUser = errors.New("user already posted this order")
 
-const createOrder = `INSERT INTO orders (user_id, number, status) VALUES ($1, $2, $3)`
+const (
+	createOrder = `INSERT INTO orders (user_id, number, status) VALUES ($1, $2, $3)`
+
+	enqueueOrder = `
+				INSERT INTO order_outbox (order_number) VALUES ($1)
+				ON CONFLICT (order_number) WHERE processed_at IS NULL DO NOTHING`
+
+	createOrderItem = `INSERT INTO order_items (order_number, description, category, price) VALUES ($1, $2, $3, $4)`
+)
 
+// CreateOrder stores the order with its goods and its processing job in the
+// outbox atomically.
 func (op *OrderPostgres) CreateOrder(ctx context.Context, order models.Order) error {
-	_, err := op.db.ExecContext(ctx, createOrder, order.UserID, order.Number, order.Status)
+	tx, err := op.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, createOrder, order.UserID, order.Number, order.Status)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
@@ -32,15 +48,40 @@ func (op *OrderPostgres) CreateOrder(ctx context.Context, order models.Order) er
 		}
 		return fmt.Errorf("faiiled to create order: %w", err)
 	}
-	return nil
+
+	for _, item := range order.Goods {
+		if _, err := tx.ExecContext(ctx, createOrderItem, order.Number, item.Description, item.Category, item.Price); err != nil {
+			return fmt.Errorf("failed to create order item: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, enqueueOrder, order.Number); err != nil {
+		return fmt.Errorf("failed to enqueue order: %w", err)
+	}
+
+	return tx.Commit()
 }
 
-const getOrders = `SELECT number, status, accrual, uploaded_at 
-					FROM orders WHERE user_id = $1 ORDER BY uploaded_at DESC`
+const getOrders = `SELECT number, status, status_reason, accrual, COALESCE(base_accrual, 0),
+						COALESCE(accrual_multiplier, 0),
+						(SELECT COALESCE(SUM(amount), 0) FROM campaign_bonuses
+							WHERE order_number = number AND status = 'CREDITED'),
+						uploaded_at 
+					FROM orders WHERE user_id = $1
+						AND ($2::TIMESTAMP IS NULL OR (uploaded_at, number) < ($2, $3::BIGINT))
+					ORDER BY uploaded_at DESC, number DESC
+					LIMIT NULLIF($4::INTEGER, 0)`
+
+// ListOrders returns the orders of the user newest first, the order number
+// breaks ties in the page cursor.
+func (op *OrderPostgres) ListOrders(ctx context.Context, userID int, page models.Page) ([]models.Order, error) {
+	var cursorAt, cursorID any
+	if page.After != nil {
+		cursorAt, cursorID = page.After.At, page.After.ID
+	}
 
-func (op *OrderPostgres) ListOrders(ctx context.Context, userID int) ([]models.Order, error) {
 	var orders []models.Order
-	rows, err := op.db.QueryContext(ctx, getOrders, userID)
+	rows, err := op.db.QueryContext(ctx, getOrders, userID, cursorAt, cursorID, page.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -48,14 +89,13 @@ func (op *OrderPostgres) ListOrders(ctx context.Context, userID int) ([]models.O
 
 	for rows.Next() {
 		var order models.Order
-		var acc sql.NullFloat64
+		var reason sql.NullString
 		var uploadedAt sql.NullTime
-		if err := rows.Scan(&order.Number, &order.Status, &acc, &uploadedAt); err != nil {
+		if err := rows.Scan(&order.Number, &order.Status, &reason, &order.Accrual,
+			&order.BaseAccrual, &order.Multiplier, &order.Bonus, &uploadedAt); err != nil {
 			return nil, err
 		}
-		if acc.Valid {
-			order.Accrual = acc.Float64
-		}
+		order.StatusReason = reason.String
 		if uploadedAt.Valid {
 			order.CreatedAt = uploadedAt.Time
 		}
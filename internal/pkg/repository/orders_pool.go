@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
@@ -12,11 +13,15 @@ import (
 )
 
 type WorkerPoolRepo struct {
-	pool *pgxpool.Pool
+	pool            *pgxpool.Pool
+	tierMultipliers bool
 }
 
-func NewWorkerPoolRepo(pool *pgxpool.Pool) *WorkerPoolRepo {
-	return &WorkerPoolRepo{pool: pool}
+// NewWorkerPoolRepo applies the multipliers of user tiers to accruals only
+// with tierMultipliers set, multipliers left from a previous run with tiers
+// enabled are ignored otherwise.
+func NewWorkerPoolRepo(pool *pgxpool.Pool, tierMultipliers bool) *WorkerPoolRepo {
+	return &WorkerPoolRepo{pool: pool, tierMultipliers: tierMultipliers}
 }
 
 const (
@@ -24,8 +29,17 @@ const (
 			UPDATE orders 
 			SET status = $2,
 				accrual = $3,
+				status_reason = NULLIF($4, ''),
+				accrual_provider = COALESCE(NULLIF($5, ''), accrual_provider),
+				base_accrual = $6,
+				accrual_multiplier = $7,
 				updated_at = NOW() 
-			WHERE number = $1`
+			WHERE number = $1 AND status NOT IN ('INVALID', 'PROCESSED')`
+
+	getAccrualMultiplier = `
+			SELECT COALESCE(t.multiplier, 1)
+			FROM orders o LEFT JOIN user_tiers t ON t.user_id = o.user_id
+			WHERE o.number = $1`
 
 	updateBalance = `
 			INSERT INTO balance (user_id, current, withdrawn) 
@@ -34,10 +48,11 @@ const (
 			)
 			ON CONFLICT (user_id) DO UPDATE 
 			SET current = balance.current + EXCLUDED.current,
-				updated_at = NOW()`
+				updated_at = NOW()
+			RETURNING user_id, current`
 )
 
-func (r *WorkerPoolRepo) UpdateOrderAndBalance(ctx context.Context, order models.Order, accrual float64) error {
+func (r *WorkerPoolRepo) UpdateOrderAndBalance(ctx context.Context, order models.Order, accrual models.Money) error {
 	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable})
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -52,21 +67,71 @@ func (r *WorkerPoolRepo) UpdateOrderAndBalance(ctx context.Context, order models
 		return fmt.Errorf("order %d is already locked", order.Number)
 	}
 
-	tag, err := tx.Exec(ctx, updateOrder, order.Number, order.Status, accrual)
+	// the tier multiplier applies to the accrual before it is credited,
+	// the order keeps both amounts
+	var baseAccrual any
+	var multiplier any
+	if order.Status == models.OrderStatusProcessed && r.tierMultipliers {
+		var m float64
+		if err := tx.QueryRow(ctx, getAccrualMultiplier, order.Number).Scan(&m); err != nil {
+			return fmt.Errorf("failed to get accrual multiplier: %w", err)
+		}
+		baseAccrual, multiplier = accrual, m
+		accrual = accrual.MulPercent(m * 100)
+	}
+
+	tag, err := tx.Exec(ctx, updateOrder, order.Number, order.Status, accrual, order.StatusReason, order.Provider,
+		baseAccrual, multiplier)
 	if err != nil {
 		return fmt.Errorf("failed to update order: %w", err)
 	}
 
+	// the outbox job is done once the order is final, until then its lease
+	// runs out and the order is looked up again
+	if order.IsFinal() {
+		if _, err := tx.Exec(ctx, completeOutbox, order.Number); err != nil {
+			return fmt.Errorf("failed to complete outbox job: %w", err)
+		}
+	}
+
 	if tag.RowsAffected() == 0 {
 		logger.Log.Sugar().Infof("No rows updated: maybe order %d already in correct state", order.Number)
 		return tx.Commit(ctx)
 	}
 
-	_, err = tx.Exec(ctx, updateBalance, order.Number, accrual)
+	if order.Status != models.OrderStatusProcessed {
+		return tx.Commit(ctx)
+	}
+
+	// the upsert also creates the balance row for orders without accrual,
+	// campaigns may still credit a bonus for them
+	var userID int
+	var current models.Money
+	err = tx.QueryRow(ctx, updateBalance, order.Number, accrual).Scan(&userID, &current)
 	if err != nil {
 		return fmt.Errorf("failed to update balance: %w", err)
 	}
 
+	if accrual > 0 {
+		if _, err := tx.Exec(ctx, addPointLot, userID, order.Number, accrual); err != nil {
+			return fmt.Errorf("failed to add point lot: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, postLedgerEntry, userID, models.LedgerEntryAccrual,
+			accrual, current, order.Number, SystemAccrualAccount)
+		if err != nil {
+			return fmt.Errorf("failed to post ledger entry: %w", err)
+		}
+	}
+
+	if err := applyCampaignBonuses(ctx, tx, userID, order.Number, accrual); err != nil {
+		return err
+	}
+
+	if err := applyReferralRewards(ctx, tx, userID, order.Number); err != nil {
+		return err
+	}
+
 	return tx.Commit(ctx)
 }
 
@@ -74,7 +139,7 @@ const (
 	getPendingOrders = `
 	SELECT user_id, number, status 
 	FROM orders 
-	WHERE status IN ('NEW', 'PROCESSING') 
+	WHERE status IN ('NEW', 'REGISTERED', 'PROCESSING') 
 	AND pg_try_advisory_xact_lock(number) 
 	ORDER BY uploaded_at ASC LIMIT $1`
 )
@@ -126,3 +191,56 @@ func (r *WorkerPoolRepo) InsertMissingOrder(ctx context.Context, orderNumber int
 	}
 	return nil
 }
+
+const (
+	claimOutbox = `
+				UPDATE order_outbox SET claimed_until = NOW() + make_interval(secs => $2)
+				WHERE id IN (
+					SELECT id FROM order_outbox
+					WHERE processed_at IS NULL
+						AND (claimed_until IS NULL OR claimed_until < NOW())
+					ORDER BY id
+					FOR UPDATE SKIP LOCKED
+					LIMIT $1
+				)
+				RETURNING order_number`
+
+	completeOutbox = `UPDATE order_outbox SET processed_at = NOW() WHERE order_number = $1 AND processed_at IS NULL`
+)
+
+// ClaimOutbox leases up to limit pending outbox jobs for lease and returns
+// their order numbers, concurrent consumers never receive the same job. Jobs
+// not completed before their lease ends are handed out again.
+func (r *WorkerPoolRepo) ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]int64, error) {
+	rows, err := r.pool.Query(ctx, claimOutbox, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox: %w", err)
+	}
+	defer rows.Close()
+
+	numbers := make([]int64, 0, limit)
+	for rows.Next() {
+		var number int64
+		if err := rows.Scan(&number); err != nil {
+			return nil, err
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, rows.Err()
+}
+
+// CompleteOutbox marks the pending outbox job of the order as processed,
+// UpdateOrderAndBalance does so itself once the order is final.
+func (r *WorkerPoolRepo) CompleteOutbox(ctx context.Context, orderNumber int64) error {
+	if _, err := r.pool.Exec(ctx, completeOutbox, orderNumber); err != nil {
+		return fmt.Errorf("failed to complete outbox job: %w", err)
+	}
+	return nil
+}
+
+func (r *WorkerPoolRepo) EnqueueOrder(ctx context.Context, orderNumber int64) error {
+	if _, err := r.pool.Exec(ctx, enqueueOrder, orderNumber); err != nil {
+		return fmt.Errorf("failed to enqueue order: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+type ReconciliationPostgres struct {
+	db *sql.DB
+}
+
+func NewReconciliationPostgres(db *sql.DB) *ReconciliationPostgres {
+	return &ReconciliationPostgres{db: db}
+}
+
+// expectedBalances recomputes balances from processed orders, campaign
+// bonuses, referral rewards, withdrawals less their reversals, expired points
+// and transfers, $1 = 0 selects every user.
+const expectedBalances = `
+				WITH expected AS (
+					SELECT u.id AS user_id,
+						COALESCE((SELECT SUM(o.accrual) FROM orders o
+							WHERE o.user_id = u.id AND o.status = 'PROCESSED'), 0) AS accrued,
+						COALESCE((SELECT SUM(cb.amount) FROM campaign_bonuses cb
+							WHERE cb.user_id = u.id AND cb.status = 'CREDITED'), 0) AS bonuses,
+						COALESCE((SELECT SUM(CASE WHEN r.referrer_id = u.id THEN r.referrer_reward ELSE r.referee_reward END)
+							FROM referrals r WHERE r.status = 'REWARDED'
+							AND (r.referrer_id = u.id OR r.referee_id = u.id)), 0) AS referrals,
+						COALESCE((SELECT SUM(w.sum - w.reversed) FROM withdrawals w WHERE w.user_id = u.id), 0) AS withdrawn,
+						COALESCE((SELECT -SUM(l.amount) FROM ledger_entries l
+							WHERE l.user_id = u.id AND l.entry_type = 'expiration'), 0) AS expired,
+						COALESCE((SELECT SUM(CASE WHEN t.recipient_id = u.id THEN t.sum ELSE -t.sum END) FROM transfers t
+							WHERE t.sender_id = u.id OR t.recipient_id = u.id), 0) AS transferred
+					FROM users u
+					WHERE $1 = 0 OR u.id = $1
+				)
+				SELECT e.user_id, e.accrued + e.bonuses + e.referrals - e.withdrawn - e.expired + e.transferred,
+					COALESCE(b.current, 0), e.withdrawn, COALESCE(b.withdrawn, 0)
+				FROM expected e LEFT JOIN balance b ON b.user_id = e.user_id
+				ORDER BY e.user_id`
+
+func (rp *ReconciliationPostgres) FindDiscrepancies(ctx context.Context) (int, []models.BalanceDiscrepancy, error) {
+	rows, err := rp.db.QueryContext(ctx, expectedBalances, 0)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to recompute balances: %w", err)
+	}
+	defer rows.Close()
+
+	checked := 0
+	discrepancies := make([]models.BalanceDiscrepancy, 0)
+	for rows.Next() {
+		var d models.BalanceDiscrepancy
+		if err := rows.Scan(&d.UserID, &d.ExpectedCurrent, &d.ActualCurrent, &d.ExpectedWithdrawn, &d.ActualWithdrawn); err != nil {
+			return 0, nil, err
+		}
+		checked++
+
+		if d.ExpectedCurrent != d.ActualCurrent || d.ExpectedWithdrawn != d.ActualWithdrawn {
+			discrepancies = append(discrepancies, d)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	return checked, discrepancies, nil
+}
+
+// ErrHeldExceedsBalance is returned by FixDiscrepancy when the corrected
+// balance would not cover the points held, the holds have to be settled first.
+var ErrHeldExceedsBalance = errors.New("held points exceed the corrected balance")
+
+const (
+	lockBalance = `SELECT current, withdrawn, held FROM balance WHERE user_id = $1 FOR UPDATE`
+
+	setBalance = `
+				UPDATE balance SET current = $2, withdrawn = $3, updated_at = NOW()
+				WHERE user_id = $1`
+)
+
+// FixDiscrepancy brings the user balance in line with orders and withdrawals
+// and records the difference as an adjustment ledger entry. It reports false
+// when the balance turned out to be consistent once locked, and fails with
+// ErrHeldExceedsBalance rather than touching holds the user is paying with.
+func (rp *ReconciliationPostgres) FixDiscrepancy(ctx context.Context, userID int) (bool, error) {
+	tx, err := rp.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, insertBalanceIfNotExists, userID); err != nil {
+		return false, fmt.Errorf("failed to insert balance row: %w", err)
+	}
+
+	var current, withdrawn, held models.Money
+	if err := tx.QueryRowContext(ctx, lockBalance, userID).Scan(&current, &withdrawn, &held); err != nil {
+		return false, fmt.Errorf("failed to lock balance: %w", err)
+	}
+
+	var d models.BalanceDiscrepancy
+	err = tx.QueryRowContext(ctx, expectedBalances, userID).
+		Scan(&d.UserID, &d.ExpectedCurrent, &d.ActualCurrent, &d.ExpectedWithdrawn, &d.ActualWithdrawn)
+	if err != nil {
+		return false, fmt.Errorf("failed to recompute balance: %w", err)
+	}
+
+	if d.ExpectedCurrent == current && d.ExpectedWithdrawn == withdrawn {
+		return false, tx.Commit()
+	}
+
+	if d.ExpectedCurrent < held {
+		return false, fmt.Errorf("%w: user %d holds %s of %s", ErrHeldExceedsBalance, userID, held, d.ExpectedCurrent)
+	}
+
+	if _, err := tx.ExecContext(ctx, setBalance, userID, d.ExpectedCurrent, d.ExpectedWithdrawn); err != nil {
+		return false, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	diff := d.ExpectedCurrent - current
+	if diff > 0 {
+		_, err = tx.ExecContext(ctx, addPointLot, userID, nil, diff)
+	} else if diff < 0 {
+		_, err = tx.ExecContext(ctx, consumePointLots, userID, -diff)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to adjust point lots: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, postLedgerEntry, userID, models.LedgerEntryAdjustment,
+		diff, d.ExpectedCurrent, nil, SystemAdjustmentAccount)
+	if err != nil {
+		return false, fmt.Errorf("failed to post ledger entry: %w", err)
+	}
+
+	return true, tx.Commit()
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/jackc/pgx/v5"
+)
+
+const SystemReferralAccount = "system:referral"
+
+type ReferralsPostgres struct {
+	db *sql.DB
+}
+
+func NewReferralsPostgres(db *sql.DB) *ReferralsPostgres {
+	return &ReferralsPostgres{db: db}
+}
+
+const (
+	getReferralCode = `SELECT referral_code FROM users WHERE id = $1`
+
+	listReferrals = `
+				SELECT u.login, r.status, r.reason, r.referrer_reward, r.created_at, r.rewarded_at
+				FROM referrals r JOIN users u ON u.id = r.referee_id
+				WHERE r.referrer_id = $1
+				ORDER BY r.created_at DESC, r.id DESC`
+)
+
+func (rp *ReferralsPostgres) ListReferrals(ctx context.Context, userID int) (models.Referrals, error) {
+	var referrals models.Referrals
+	if err := rp.db.QueryRowContext(ctx, getReferralCode, userID).Scan(&referrals.Code); err != nil {
+		return models.Referrals{}, fmt.Errorf("failed to get referral code: %w", err)
+	}
+
+	rows, err := rp.db.QueryContext(ctx, listReferrals, userID)
+	if err != nil {
+		return models.Referrals{}, fmt.Errorf("failed to list referrals: %w", err)
+	}
+	defer rows.Close()
+
+	referrals.Referrals = make([]models.Referral, 0)
+	for rows.Next() {
+		var r models.Referral
+		var rewardedAt sql.NullTime
+		if err := rows.Scan(&r.Login, &r.Status, &r.Reason, &r.Reward, &r.CreatedAt, &rewardedAt); err != nil {
+			return models.Referrals{}, err
+		}
+		if rewardedAt.Valid {
+			r.RewardedAt = &rewardedAt.Time
+		}
+		referrals.Referrals = append(referrals.Referrals, r)
+	}
+	return referrals, rows.Err()
+}
+
+const (
+	rewardPendingReferral = `
+				UPDATE referrals SET status = 'REWARDED', rewarded_at = NOW()
+				WHERE referee_id = $1 AND status = 'PENDING'
+				RETURNING referrer_id, referrer_reward, referee_reward`
+
+	creditBalanceOnReferral = `
+				INSERT INTO balance (user_id, current, withdrawn) VALUES ($1, $2, 0)
+				ON CONFLICT (user_id) DO UPDATE
+				SET current = balance.current + EXCLUDED.current,
+					updated_at = NOW()
+				RETURNING current`
+)
+
+// applyReferralRewards pays out a pending referral of the user, it runs in the
+// transaction that processed the order so only the first processed order of
+// the referee rewards both sides.
+func applyReferralRewards(ctx context.Context, tx pgx.Tx, userID int, orderNumber int64) error {
+	var referrerID int
+	var referrerReward, refereeReward models.Money
+	err := tx.QueryRow(ctx, rewardPendingReferral, userID).Scan(&referrerID, &referrerReward, &refereeReward)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to reward referral: %w", err)
+	}
+
+	// the referee's reward is tied to the order, the referrer's is not
+	credits := []struct {
+		userID int
+		amount models.Money
+		order  any
+	}{
+		{userID: userID, amount: refereeReward, order: orderNumber},
+		{userID: referrerID, amount: referrerReward},
+	}
+	for _, c := range credits {
+		if c.amount <= 0 {
+			continue
+		}
+
+		var current models.Money
+		if err := tx.QueryRow(ctx, creditBalanceOnReferral, c.userID, c.amount).Scan(&current); err != nil {
+			return fmt.Errorf("failed to credit referral reward: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, addPointLot, c.userID, c.order, c.amount); err != nil {
+			return fmt.Errorf("failed to add point lot: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, postLedgerEntry, c.userID, models.LedgerEntryReferral,
+			c.amount, current, c.order, SystemReferralAccount)
+		if err != nil {
+			return fmt.Errorf("failed to post ledger entry: %w", err)
+		}
+	}
+	return nil
+}
@@ -3,25 +3,98 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
 )
 
 type AuthorizationRepository interface {
-	CreateUser(ctx context.Context, user models.User) (int, error)
+	CreateUser(ctx context.Context, user models.User, rewards models.ReferralRewards) (int, error)
 	GetUser(ctx context.Context, login, password string) (models.User, error)
+	UpdatePassword(ctx context.Context, userID int, oldPassword, newPassword string) error
 }
 
 type OrderRepository interface {
 	CreateOrder(ctx context.Context, order models.Order) error
-	ListOrders(ctx context.Context, userID int) ([]models.Order, error)
+	ListOrders(ctx context.Context, userID int, page models.Page) ([]models.Order, error)
 	CheckOrderStatus(ctx context.Context, orderID int64, userID int) (string, error)
 }
 
 type BalanceRepository interface {
 	DisplayUserBalance(ctx context.Context, userID int) (models.Balance, error)
-	WithdrawLoyaltyPoints(ctx context.Context, userID int, withdraw models.WithdrawRequest) error
-	DisplayWithdrawals(ctx context.Context, userID int) ([]models.Withdrawal, error)
+	WithdrawLoyaltyPoints(ctx context.Context, userID int, withdraw models.WithdrawRequest,
+		limits models.WithdrawalLimits, accrual models.WithdrawalAccrualPolicy) error
+	StreamWithdrawals(ctx context.Context, userID int, filter models.WithdrawalFilter, fn func(models.Withdrawal) error) error
+	ReverseWithdrawal(ctx context.Context, order int64, reversal models.ReversalRequest) (models.Withdrawal, error)
+	GetStatement(ctx context.Context, userID int, cursor int64, limit int) (models.Statement, error)
+}
+
+type HoldsRepository interface {
+	CreateHold(ctx context.Context, userID int, sum models.Money, ttl time.Duration) (models.Hold, error)
+	CaptureHold(ctx context.Context, userID int, holdID int64, capture models.WithdrawRequest,
+		limits models.WithdrawalLimits, accrual models.WithdrawalAccrualPolicy) (models.Hold, error)
+	ReleaseHold(ctx context.Context, userID int, holdID int64) (models.Hold, error)
+	ExpireHolds(ctx context.Context) (int64, error)
+}
+
+type PointLotsRepository interface {
+	UsersWithExpiredLots(ctx context.Context) ([]int, error)
+	ExpireUserPoints(ctx context.Context, userID int) (models.Money, error)
+}
+
+type TransfersRepository interface {
+	CreateTransfer(ctx context.Context, senderID int, req models.TransferRequest,
+		limits models.TransferLimits, withdrawalLimits models.WithdrawalLimits) (models.Transfer, error)
+	ListTransfers(ctx context.Context, userID int, page models.Page) ([]models.Transfer, error)
+}
+
+type ReferralsRepository interface {
+	ListReferrals(ctx context.Context, userID int) (models.Referrals, error)
+}
+
+type StatementsRepository interface {
+	GetCachedStatement(ctx context.Context, userID int, month time.Time) (models.MonthlyStatement, bool, error)
+	SaveStatement(ctx context.Context, userID int, month time.Time, statement models.MonthlyStatement) error
+	BuildStatement(ctx context.Context, userID int, month time.Time) (models.MonthlyStatement, error)
+}
+
+type RiskRepository interface {
+	RiskStats(ctx context.Context, userID int, window time.Duration) (models.RiskStats, error)
+	CreateReview(ctx context.Context, event models.RiskEvent, decision models.RiskDecision) (models.RiskReview, error)
+	ListReviews(ctx context.Context, status string) ([]models.RiskReview, error)
+	ResolveReview(ctx context.Context, id int64, resolution models.ReviewResolution) (models.RiskReview, error)
+}
+
+type TiersRepository interface {
+	RecomputeTiers(ctx context.Context, tiers []models.Tier) (int64, error)
+	GetUserTier(ctx context.Context, userID int) (models.UserTier, error)
+}
+
+type CampaignsRepository interface {
+	CreateCampaign(ctx context.Context, c models.Campaign) (models.Campaign, error)
+	UpdateCampaign(ctx context.Context, c models.Campaign) (models.Campaign, error)
+	GetCampaign(ctx context.Context, id int) (models.Campaign, error)
+	ListCampaigns(ctx context.Context) ([]models.Campaign, error)
+	DeleteCampaign(ctx context.Context, id int) error
+	ListBonuses(ctx context.Context, campaignID int) ([]models.CampaignBonus, error)
+	ReverseBonus(ctx context.Context, bonusID int64) (models.CampaignBonus, error)
+}
+
+type AccrualRulesRepository interface {
+	LoadRules(ctx context.Context) ([]models.AccrualRule, error)
+	ListOrderItems(ctx context.Context, orderNumber int64) ([]models.OrderItem, error)
+}
+
+type ReconciliationRepository interface {
+	FindDiscrepancies(ctx context.Context) (int, []models.BalanceDiscrepancy, error)
+	FixDiscrepancy(ctx context.Context, userID int) (bool, error)
+}
+
+type IdempotencyRepository interface {
+	AcquireKey(ctx context.Context, userID int, key, requestHash string, ttl time.Duration) (bool, models.IdempotencyRecord, error)
+	CompleteKey(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error
+	ReleaseKey(ctx context.Context, userID int, key string) error
+	PurgeExpiredKeys(ctx context.Context) (int64, error)
 }
 
 type ProcessOrderWP interface {
@@ -31,23 +104,48 @@ type ProcessOrderWP interface {
 }
 
 type WorkerPoolRepository interface {
-	UpdateOrderAndBalance(ctx context.Context, order models.Order, accrual float64) error
+	UpdateOrderAndBalance(ctx context.Context, order models.Order, accrual models.Money) error
 	GetPendingOrders(ctx context.Context, limit int) ([]models.Order, error)
 	LockAndGetOrderStatus(ctx context.Context, orderNumber int64) (string, error)
 	InsertMissingOrder(ctx context.Context, orderNumber int64) error
+	ClaimOutbox(ctx context.Context, limit int, lease time.Duration) ([]int64, error)
+	CompleteOutbox(ctx context.Context, orderNumber int64) error
+	EnqueueOrder(ctx context.Context, orderNumber int64) error
 }
 
 type Repository struct {
-	Authorization AuthorizationRepository
-	Order         OrderRepository
-	Balance       BalanceRepository
-	WPRepository  WorkerPoolRepository
+	Authorization  AuthorizationRepository
+	Order          OrderRepository
+	Balance        BalanceRepository
+	Holds          HoldsRepository
+	PointLots      PointLotsRepository
+	Transfers      TransfersRepository
+	Referrals      ReferralsRepository
+	Statements     StatementsRepository
+	Risk           RiskRepository
+	Tiers          TiersRepository
+	Campaigns      CampaignsRepository
+	AccrualRules   AccrualRulesRepository
+	Reconciliation ReconciliationRepository
+	Idempotency    IdempotencyRepository
+	WPRepository   WorkerPoolRepository
 }
 
 func NewRepository(db *sql.DB) *Repository {
 	return &Repository{
-		Authorization: NewAuthPostgres(db),
-		Order:         NewOrderPostgres(db),
-		Balance:       NewBalancePostgres(db),
+		Authorization:  NewAuthPostgres(db),
+		Order:          NewOrderPostgres(db),
+		Balance:        NewBalancePostgres(db),
+		Holds:          NewHoldsPostgres(db),
+		PointLots:      NewPointLotsPostgres(db),
+		Transfers:      NewTransfersPostgres(db),
+		Referrals:      NewReferralsPostgres(db),
+		Statements:     NewStatementsPostgres(db),
+		Risk:           NewRiskPostgres(db),
+		Tiers:          NewTiersPostgres(db),
+		Campaigns:      NewCampaignsPostgres(db),
+		AccrualRules:   NewAccrualRulesPostgres(db),
+		Reconciliation: NewReconciliationPostgres(db),
+		Idempotency:    NewIdempotencyPostgres(db),
 	}
 }
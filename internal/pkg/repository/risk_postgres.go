@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+type RiskPostgres struct {
+	db *sql.DB
+}
+
+func NewRiskPostgres(db *sql.DB) *RiskPostgres {
+	return &RiskPostgres{db: db}
+}
+
+var (
+	ErrReviewNotFound        = errors.New("risk review not found")
+	ErrReviewAlreadyResolved = errors.New("risk review is already resolved")
+)
+
+const getRiskStats = `
+				SELECT
+					(SELECT COUNT(*) FROM orders o
+						WHERE o.user_id = u.id AND o.uploaded_at > LOCALTIMESTAMP - make_interval(secs => $2)),
+					(SELECT COUNT(*) FROM orders o WHERE o.user_id = u.id),
+					(SELECT COUNT(*) FROM orders o WHERE o.user_id = u.id AND o.status = 'INVALID'),
+					EXTRACT(EPOCH FROM LOCALTIMESTAMP - COALESCE(u.created_at, LOCALTIMESTAMP))::BIGINT,
+					(SELECT COUNT(*) FROM users other
+						WHERE u.registered_ip <> '' AND other.registered_ip = u.registered_ip AND other.id <> u.id)
+				FROM users u WHERE u.id = $1`
+
+// RiskStats collects the account history of the user, uploads are counted
+// within the last window.
+func (rp *RiskPostgres) RiskStats(ctx context.Context, userID int, window time.Duration) (models.RiskStats, error) {
+	var stats models.RiskStats
+	var ageSeconds int64
+	err := rp.db.QueryRowContext(ctx, getRiskStats, userID, window.Seconds()).
+		Scan(&stats.RecentUploads, &stats.Orders, &stats.InvalidOrders, &ageSeconds, &stats.AccountsSameIP)
+	if err != nil {
+		return models.RiskStats{}, fmt.Errorf("failed to get risk stats: %w", err)
+	}
+	stats.AccountAge = time.Duration(ageSeconds) * time.Second
+	return stats, nil
+}
+
+const (
+	insertRiskReview = `
+				INSERT INTO risk_reviews (user_id, event_kind, order_number, sum, score, action, reasons)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				RETURNING id, created_at`
+
+	riskReviewColumns = `id, user_id, event_kind, order_number, sum, score, action, reasons, status, note, created_at, reviewed_at`
+
+	listRiskReviews = `
+				SELECT ` + riskReviewColumns + ` FROM risk_reviews
+				WHERE $1 = '' OR status = $1
+				ORDER BY id`
+
+	resolveRiskReview = `
+				UPDATE risk_reviews SET status = $2, note = $3, reviewed_at = NOW()
+				WHERE id = $1 AND status = 'PENDING'
+				RETURNING ` + riskReviewColumns
+
+	getRiskReviewStatus = `SELECT status FROM risk_reviews WHERE id = $1`
+)
+
+func (rp *RiskPostgres) CreateReview(ctx context.Context, event models.RiskEvent, decision models.RiskDecision) (models.RiskReview, error) {
+	reasons, err := json.Marshal(decision.Reasons)
+	if err != nil {
+		return models.RiskReview{}, err
+	}
+
+	review := models.RiskReview{Event: event, Decision: decision, Status: models.ReviewStatusPending}
+	err = rp.db.QueryRowContext(ctx, insertRiskReview, event.UserID, event.Kind, event.Order, event.Sum,
+		decision.Score, decision.Action, reasons).Scan(&review.ID, &review.CreatedAt)
+	if err != nil {
+		return models.RiskReview{}, fmt.Errorf("failed to insert risk review: %w", err)
+	}
+	return review, nil
+}
+
+// ListReviews returns reviews in the given status, empty status returns all.
+func (rp *RiskPostgres) ListReviews(ctx context.Context, status string) ([]models.RiskReview, error) {
+	rows, err := rp.db.QueryContext(ctx, listRiskReviews, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list risk reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := make([]models.RiskReview, 0)
+	for rows.Next() {
+		review, err := scanRiskReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (rp *RiskPostgres) ResolveReview(ctx context.Context, id int64, resolution models.ReviewResolution) (models.RiskReview, error) {
+	review, err := scanRiskReview(rp.db.QueryRowContext(ctx, resolveRiskReview, id, resolution.Status, resolution.Note))
+	if err == nil {
+		return review, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.RiskReview{}, fmt.Errorf("failed to resolve risk review: %w", err)
+	}
+
+	var status string
+	if err := rp.db.QueryRowContext(ctx, getRiskReviewStatus, id).Scan(&status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.RiskReview{}, ErrReviewNotFound
+		}
+		return models.RiskReview{}, fmt.Errorf("failed to get risk review: %w", err)
+	}
+	return models.RiskReview{}, ErrReviewAlreadyResolved
+}
+
+func scanRiskReview(row rowScanner) (models.RiskReview, error) {
+	var review models.RiskReview
+	var reasons []byte
+	var reviewedAt sql.NullTime
+	err := row.Scan(&review.ID, &review.Event.UserID, &review.Event.Kind, &review.Event.Order, &review.Event.Sum,
+		&review.Decision.Score, &review.Decision.Action, &reasons, &review.Status, &review.Note,
+		&review.CreatedAt, &reviewedAt)
+	if err != nil {
+		return models.RiskReview{}, err
+	}
+	if err := json.Unmarshal(reasons, &review.Decision.Reasons); err != nil {
+		return models.RiskReview{}, fmt.Errorf("failed to decode risk reasons: %w", err)
+	}
+	if reviewedAt.Valid {
+		review.ReviewedAt = &reviewedAt.Time
+	}
+	return review, nil
+}
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE accrual_rules (
+    id TEXT PRIMARY KEY,
+    type TEXT NOT NULL CHECK (type IN ('percent', 'fixed', 'cap')),
+    category TEXT,
+    value NUMERIC(20, 2) NOT NULL,
+    cap NUMERIC(20, 2),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    position INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE order_items (
+    id SERIAL PRIMARY KEY,
+    order_number BIGINT NOT NULL REFERENCES orders(number) ON DELETE CASCADE,
+    description TEXT NOT NULL,
+    category TEXT NOT NULL DEFAULT '',
+    price NUMERIC(20, 2) NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE order_items;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE accrual_rules;
+-- +goose StatementEnd
@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS order_notification ON orders;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS notify_new_order();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE order_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    order_number BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX order_outbox_pending_idx ON order_outbox (order_number) WHERE processed_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_order_outbox()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('order_notifications', NEW.order_number::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER order_outbox_notification
+AFTER INSERT ON order_outbox
+FOR EACH ROW EXECUTE FUNCTION notify_order_outbox();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS order_outbox_notification ON order_outbox;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS notify_order_outbox();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE order_outbox;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_new_order()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('order_notifications', NEW.number::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER order_notification
+AFTER INSERT ON orders
+FOR EACH ROW EXECUTE FUNCTION notify_new_order();
+-- +goose StatementEnd
@@ -0,0 +1,58 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE SEQUENCE ledger_transaction_seq;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL,
+    account TEXT NOT NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    entry_type TEXT NOT NULL CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal')),
+    amount NUMERIC(20, 2) NOT NULL,
+    balance_after NUMERIC(20, 2),
+    order_number BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX ledger_entries_user_idx ON ledger_entries (user_id, id) WHERE user_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX ledger_entries_transaction_idx ON ledger_entries (transaction_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+WITH events AS (
+    SELECT user_id, 'accrual' AS entry_type, accrual AS amount, number AS order_number, updated_at AS created_at
+    FROM orders WHERE status = 'PROCESSED' AND accrual > 0
+    UNION ALL
+    SELECT user_id, 'withdrawal', -sum, order_id, processed_at
+    FROM withdrawals
+), numbered AS (
+    SELECT nextval('ledger_transaction_seq') AS transaction_id, e.*,
+        SUM(e.amount) OVER (PARTITION BY e.user_id ORDER BY e.created_at, e.order_number
+            ROWS UNBOUNDED PRECEDING) AS balance_after
+    FROM events e
+)
+INSERT INTO ledger_entries (transaction_id, account, user_id, entry_type, amount, balance_after, order_number, created_at)
+SELECT transaction_id, 'user:' || user_id, user_id, entry_type, amount, balance_after, order_number, created_at
+FROM numbered
+UNION ALL
+SELECT transaction_id,
+    CASE entry_type WHEN 'accrual' THEN 'system:accrual' ELSE 'system:redemption' END,
+    NULL, entry_type, -amount, NULL, order_number, created_at
+FROM numbered;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE ledger_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP SEQUENCE ledger_transaction_seq;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE idempotency_keys (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER,
+    content_type TEXT,
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX idempotency_keys_expires_idx ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- retried requests could withdraw twice for the same order, every withdrawal
+-- but the first one of an order is refunded before the order becomes unique
+CREATE TEMPORARY TABLE duplicate_withdrawals ON COMMIT DROP AS
+SELECT id, user_id, order_id, sum, nextval('ledger_transaction_seq') AS transaction_id
+FROM (
+    SELECT w.*, ROW_NUMBER() OVER (PARTITION BY order_id ORDER BY processed_at, id) AS n
+    FROM withdrawals w
+) w
+WHERE n > 1;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE balance b
+SET current = b.current + d.total,
+    withdrawn = b.withdrawn - d.total,
+    updated_at = NOW()
+FROM (SELECT user_id, SUM(sum) AS total FROM duplicate_withdrawals GROUP BY user_id) d
+WHERE b.user_id = d.user_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+WITH refunds AS (
+    SELECT d.*, b.current - (SUM(d.sum) OVER (PARTITION BY d.user_id ORDER BY d.id DESC
+        ROWS UNBOUNDED PRECEDING) - d.sum) AS balance_after
+    FROM duplicate_withdrawals d JOIN balance b ON b.user_id = d.user_id
+)
+INSERT INTO ledger_entries (transaction_id, account, user_id, entry_type, amount, balance_after, order_number)
+SELECT transaction_id, 'user:' || user_id, user_id, 'reversal', sum, balance_after, order_id FROM refunds
+UNION ALL
+SELECT transaction_id, 'system:redemption', NULL, 'reversal', -sum, NULL, order_id FROM refunds;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DELETE FROM withdrawals WHERE id IN (SELECT id FROM duplicate_withdrawals);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE withdrawals ADD CONSTRAINT withdrawals_order_id_key UNIQUE (order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE withdrawals DROP CONSTRAINT withdrawals_order_id_key;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE withdrawals
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'COMPLETED' CHECK (status IN ('PENDING', 'COMPLETED', 'REVERSED')),
+    ADD COLUMN reversed NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (reversed >= 0 AND reversed <= sum);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE withdrawal_reversals (
+    id SERIAL PRIMARY KEY,
+    withdrawal_id INTEGER NOT NULL REFERENCES withdrawals(id) ON DELETE CASCADE,
+    sum NUMERIC(20, 2) NOT NULL CHECK (sum > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX withdrawal_reversals_withdrawal_idx ON withdrawal_reversals (withdrawal_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE withdrawal_reversals;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE withdrawals DROP COLUMN reversed, DROP COLUMN status;
+-- +goose StatementEnd
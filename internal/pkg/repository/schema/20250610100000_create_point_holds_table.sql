@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE balance ADD COLUMN held NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (held >= 0 AND held <= current);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE point_holds (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sum NUMERIC(20, 2) NOT NULL CHECK (sum > 0),
+    status TEXT NOT NULL DEFAULT 'HELD' CHECK (status IN ('HELD', 'CAPTURED', 'RELEASED', 'EXPIRED')),
+    order_id BIGINT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX point_holds_active_idx ON point_holds (expires_at) WHERE status = 'HELD';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE point_holds;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE balance DROP COLUMN held;
+-- +goose StatementEnd
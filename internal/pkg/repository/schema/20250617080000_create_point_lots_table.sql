@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE point_lots (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_number BIGINT,
+    amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+    remaining NUMERIC(20, 2) NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+    earned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX point_lots_open_idx ON point_lots (user_id, expires_at, id) WHERE remaining > 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiration'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- existing points were spent oldest first, so whatever is left of the
+-- current balance belongs to the latest accruals
+WITH accruals AS (
+    SELECT o.user_id, o.number, o.accrual, o.updated_at,
+        SUM(o.accrual) OVER (PARTITION BY o.user_id ORDER BY o.updated_at DESC, o.number DESC
+            ROWS UNBOUNDED PRECEDING) AS newer_total
+    FROM orders o
+    WHERE o.status = 'PROCESSED' AND o.accrual > 0
+)
+INSERT INTO point_lots (user_id, order_number, amount, remaining, earned_at, expires_at)
+SELECT a.user_id, a.number, a.accrual,
+    GREATEST(0, LEAST(a.accrual, b.current - (a.newer_total - a.accrual))),
+    a.updated_at, a.updated_at + INTERVAL '12 months'
+FROM accruals a JOIN balance b ON b.user_id = a.user_id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE point_lots;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DELETE FROM ledger_entries WHERE entry_type = 'expiration';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal'));
+-- +goose StatementEnd
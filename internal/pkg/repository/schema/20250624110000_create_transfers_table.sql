@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE transfers (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    sum NUMERIC(20, 2) NOT NULL CHECK (sum > 0),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (sender_id <> recipient_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX transfers_sender_idx ON transfers (sender_id, created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX transfers_recipient_idx ON transfers (recipient_id, created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiration', 'transfer'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM ledger_entries WHERE entry_type = 'transfer';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiration'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE transfers;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE user_tiers (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    tier TEXT NOT NULL DEFAULT '',
+    multiplier NUMERIC(6, 4) NOT NULL DEFAULT 1 CHECK (multiplier > 0),
+    rolling_accrual NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE orders
+    ADD COLUMN base_accrual NUMERIC(20, 2),
+    ADD COLUMN accrual_multiplier NUMERIC(6, 4);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE orders SET base_accrual = accrual, accrual_multiplier = 1 WHERE status = 'PROCESSED';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE orders DROP COLUMN accrual_multiplier, DROP COLUMN base_accrual;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE user_tiers;
+-- +goose StatementEnd
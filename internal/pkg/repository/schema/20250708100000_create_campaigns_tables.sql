@@ -0,0 +1,67 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE campaigns (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('multiplier', 'fixed')),
+    multiplier NUMERIC(6, 4) NOT NULL DEFAULT 0,
+    points NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    eligibility JSONB NOT NULL DEFAULT '{}',
+    budget NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (budget >= 0),
+    spent NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (spent >= 0),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE campaign_bonuses (
+    id BIGSERIAL PRIMARY KEY,
+    campaign_id INTEGER NOT NULL REFERENCES campaigns(id),
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    order_number BIGINT NOT NULL,
+    amount NUMERIC(20, 2) NOT NULL CHECK (amount > 0),
+    status TEXT NOT NULL DEFAULT 'CREDITED' CHECK (status IN ('CREDITED', 'REVERSED')),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reversed_at TIMESTAMP,
+    UNIQUE (campaign_id, order_number)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX campaign_bonuses_user_idx ON campaign_bonuses (user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiration', 'transfer', 'bonus'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM ledger_entries WHERE entry_type = 'bonus';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiration', 'transfer'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE campaign_bonuses;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE campaigns;
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+    ADD COLUMN referral_code TEXT UNIQUE,
+    ADD COLUMN registered_ip TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE users SET referral_code = upper(substr(md5(random()::TEXT || id::TEXT), 1, 8));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users ALTER COLUMN referral_code SET NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE referrals (
+    id SERIAL PRIMARY KEY,
+    referrer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    referee_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'REWARDED', 'REJECTED')),
+    reason TEXT NOT NULL DEFAULT '',
+    referrer_reward NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    referee_reward NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    rewarded_at TIMESTAMP,
+    CHECK (referrer_id <> referee_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX referrals_referrer_idx ON referrals (referrer_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiration', 'transfer', 'bonus', 'referral'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM ledger_entries WHERE entry_type = 'referral';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiration', 'transfer', 'bonus'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE referrals;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN registered_ip, DROP COLUMN referral_code;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- statements of closed months never change, they are generated once and kept
+CREATE TABLE monthly_statements (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    month DATE NOT NULL,
+    statement JSONB NOT NULL,
+    generated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, month)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE monthly_statements;
+-- +goose StatementEnd
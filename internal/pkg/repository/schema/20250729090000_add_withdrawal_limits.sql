@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- withdrawals are blocked for a while after a password change, a stolen
+-- session can not be used to change the password and drain the balance
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX withdrawals_user_processed_idx ON withdrawals (user_id, processed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX withdrawals_user_processed_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN password_changed_at;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE risk_reviews (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event_kind TEXT NOT NULL CHECK (event_kind IN ('order_upload', 'withdrawal')),
+    order_number BIGINT NOT NULL,
+    sum NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    score INTEGER NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('flag', 'block')),
+    reasons JSONB NOT NULL DEFAULT '[]',
+    status TEXT NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED')),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX risk_reviews_status_idx ON risk_reviews (status, id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX orders_user_uploaded_idx ON orders (user_id, uploaded_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX users_registered_ip_idx ON users (registered_ip);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_registered_ip_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX orders_user_uploaded_idx;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE risk_reviews;
+-- +goose StatementEnd
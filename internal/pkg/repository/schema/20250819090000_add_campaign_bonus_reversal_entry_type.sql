@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiration', 'transfer', 'bonus',
+        'referral', 'campaign_bonus_reversal'));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- bonus reversals used to be posted as withdrawal reversals
+UPDATE ledger_entries SET entry_type = 'campaign_bonus_reversal'
+WHERE entry_type = 'reversal' AND transaction_id IN (
+    SELECT transaction_id FROM ledger_entries WHERE entry_type = 'reversal' AND account = 'system:campaign'
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE ledger_entries SET entry_type = 'reversal' WHERE entry_type = 'campaign_bonus_reversal';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries DROP CONSTRAINT ledger_entries_entry_type_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_entry_type_check
+    CHECK (entry_type IN ('accrual', 'withdrawal', 'adjustment', 'reversal', 'expiration', 'transfer', 'bonus', 'referral'));
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE risk_reviews DROP CONSTRAINT risk_reviews_event_kind_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE risk_reviews ADD CONSTRAINT risk_reviews_event_kind_check
+    CHECK (event_kind IN ('order_upload', 'withdrawal', 'hold_capture', 'transfer'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM risk_reviews WHERE event_kind IN ('hold_capture', 'transfer');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE risk_reviews DROP CONSTRAINT risk_reviews_event_kind_check;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE risk_reviews ADD CONSTRAINT risk_reviews_event_kind_check
+    CHECK (event_kind IN ('order_upload', 'withdrawal'));
+-- +goose StatementEnd
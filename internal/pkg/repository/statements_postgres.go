@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+type StatementsPostgres struct {
+	db *sql.DB
+}
+
+func NewStatementsPostgres(db *sql.DB) *StatementsPostgres {
+	return &StatementsPostgres{db: db}
+}
+
+const (
+	getCachedStatement = `SELECT statement FROM monthly_statements WHERE user_id = $1 AND month = $2`
+
+	saveStatement = `
+				INSERT INTO monthly_statements (user_id, month, statement) VALUES ($1, $2, $3)
+				ON CONFLICT (user_id, month) DO NOTHING`
+)
+
+// GetCachedStatement returns a statement generated earlier, the flag is false
+// when there is none.
+func (sp *StatementsPostgres) GetCachedStatement(ctx context.Context, userID int, month time.Time) (models.MonthlyStatement, bool, error) {
+	var data []byte
+	err := sp.db.QueryRowContext(ctx, getCachedStatement, userID, month).Scan(&data)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.MonthlyStatement{}, false, nil
+		}
+		return models.MonthlyStatement{}, false, fmt.Errorf("failed to get cached statement: %w", err)
+	}
+
+	var statement models.MonthlyStatement
+	if err := json.Unmarshal(data, &statement); err != nil {
+		return models.MonthlyStatement{}, false, fmt.Errorf("failed to decode cached statement: %w", err)
+	}
+	return statement, true, nil
+}
+
+func (sp *StatementsPostgres) SaveStatement(ctx context.Context, userID int, month time.Time, statement models.MonthlyStatement) error {
+	data, err := json.Marshal(statement)
+	if err != nil {
+		return fmt.Errorf("failed to encode statement: %w", err)
+	}
+	if _, err := sp.db.ExecContext(ctx, saveStatement, userID, month, data); err != nil {
+		return fmt.Errorf("failed to save statement: %w", err)
+	}
+	return nil
+}
+
+// statement queries arguments: user id, month start, next month start.
+const (
+	getStatementBalances = `
+				SELECT
+					COALESCE(SUM(amount) FILTER (WHERE created_at < $2), 0),
+					COALESCE(SUM(amount) FILTER (WHERE created_at >= $2 AND amount > 0), 0),
+					COALESCE(-SUM(amount) FILTER (WHERE created_at >= $2 AND amount < 0), 0)
+				FROM ledger_entries
+				WHERE user_id = $1 AND created_at < $3`
+
+	getStatementTotals = `
+				SELECT entry_type,
+					COALESCE(SUM(amount) FILTER (WHERE amount > 0), 0),
+					COALESCE(-SUM(amount) FILTER (WHERE amount < 0), 0)
+				FROM ledger_entries
+				WHERE user_id = $1 AND created_at >= $2 AND created_at < $3
+				GROUP BY entry_type ORDER BY entry_type`
+
+	getStatementOrders = `
+				SELECT number, accrual, updated_at FROM orders
+				WHERE user_id = $1 AND status = 'PROCESSED' AND updated_at >= $2 AND updated_at < $3
+				ORDER BY updated_at, number`
+
+	getStatementWithdrawals = `
+				SELECT order_id, sum, processed_at FROM withdrawals
+				WHERE user_id = $1 AND processed_at >= $2 AND processed_at < $3
+				ORDER BY processed_at, id`
+)
+
+// BuildStatement aggregates the ledger of the month starting at month. The
+// ledger covers every balance change, processed orders and withdrawals are
+// listed for reference.
+func (sp *StatementsPostgres) BuildStatement(ctx context.Context, userID int, month time.Time) (models.MonthlyStatement, error) {
+	tx, err := sp.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return models.MonthlyStatement{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	next := month.AddDate(0, 1, 0)
+	statement := models.MonthlyStatement{
+		Month:       month.Format("2006-01"),
+		Totals:      make([]models.StatementTotal, 0),
+		Orders:      make([]models.StatementOrder, 0),
+		Withdrawals: make([]models.StatementWithdrawal, 0),
+	}
+
+	err = tx.QueryRowContext(ctx, getStatementBalances, userID, month, next).
+		Scan(&statement.OpeningBalance, &statement.Credits, &statement.Debits)
+	if err != nil {
+		return models.MonthlyStatement{}, fmt.Errorf("failed to get statement balances: %w", err)
+	}
+	statement.ClosingBalance = statement.OpeningBalance + statement.Credits - statement.Debits
+
+	rows, err := tx.QueryContext(ctx, getStatementTotals, userID, month, next)
+	if err != nil {
+		return models.MonthlyStatement{}, fmt.Errorf("failed to get statement totals: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var t models.StatementTotal
+		if err := rows.Scan(&t.Type, &t.Credits, &t.Debits); err != nil {
+			return models.MonthlyStatement{}, err
+		}
+		statement.Totals = append(statement.Totals, t)
+	}
+	if err := rows.Err(); err != nil {
+		return models.MonthlyStatement{}, err
+	}
+
+	orders, err := tx.QueryContext(ctx, getStatementOrders, userID, month, next)
+	if err != nil {
+		return models.MonthlyStatement{}, fmt.Errorf("failed to get statement orders: %w", err)
+	}
+	defer orders.Close()
+	for orders.Next() {
+		var o models.StatementOrder
+		if err := orders.Scan(&o.Number, &o.Accrual, &o.ProcessedAt); err != nil {
+			return models.MonthlyStatement{}, err
+		}
+		statement.Orders = append(statement.Orders, o)
+	}
+	if err := orders.Err(); err != nil {
+		return models.MonthlyStatement{}, err
+	}
+
+	withdrawals, err := tx.QueryContext(ctx, getStatementWithdrawals, userID, month, next)
+	if err != nil {
+		return models.MonthlyStatement{}, fmt.Errorf("failed to get statement withdrawals: %w", err)
+	}
+	defer withdrawals.Close()
+	for withdrawals.Next() {
+		var w models.StatementWithdrawal
+		if err := withdrawals.Scan(&w.Order, &w.Sum, &w.ProcessedAt); err != nil {
+			return models.MonthlyStatement{}, err
+		}
+		statement.Withdrawals = append(statement.Withdrawals, w)
+	}
+	if err := withdrawals.Err(); err != nil {
+		return models.MonthlyStatement{}, err
+	}
+
+	statement.GeneratedAt = time.Now().UTC()
+	return statement, tx.Commit()
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+type TiersPostgres struct {
+	db *sql.DB
+}
+
+func NewTiersPostgres(db *sql.DB) *TiersPostgres {
+	return &TiersPostgres{db: db}
+}
+
+// recomputeTiers assigns every user the highest tier whose threshold is
+// reached by the base accruals of the last 12 months. Tiers are passed as
+// arrays of names, thresholds and multipliers.
+const recomputeTiers = `
+				WITH tiers AS (
+					SELECT * FROM unnest($1::TEXT[], $2::NUMERIC[], $3::NUMERIC[]) AS t(name, threshold, multiplier)
+				), rolling AS (
+					SELECT u.id AS user_id, COALESCE(SUM(COALESCE(o.base_accrual, o.accrual)), 0) AS accrued
+					FROM users u
+					LEFT JOIN orders o ON o.user_id = u.id AND o.status = 'PROCESSED'
+						AND o.updated_at >= NOW() - INTERVAL '12 months'
+					GROUP BY u.id
+				)
+				INSERT INTO user_tiers (user_id, tier, multiplier, rolling_accrual, updated_at)
+				SELECT r.user_id, COALESCE(t.name, ''), COALESCE(t.multiplier, 1), r.accrued, NOW()
+				FROM rolling r
+				LEFT JOIN LATERAL (
+					SELECT name, multiplier FROM tiers
+					WHERE threshold <= r.accrued
+					ORDER BY threshold DESC LIMIT 1
+				) t ON TRUE
+				ON CONFLICT (user_id) DO UPDATE
+				SET tier = EXCLUDED.tier,
+					multiplier = EXCLUDED.multiplier,
+					rolling_accrual = EXCLUDED.rolling_accrual,
+					updated_at = EXCLUDED.updated_at`
+
+func (tp *TiersPostgres) RecomputeTiers(ctx context.Context, tiers []models.Tier) (int64, error) {
+	names := make([]string, 0, len(tiers))
+	thresholds := make([]string, 0, len(tiers))
+	multipliers := make([]float64, 0, len(tiers))
+	for _, tier := range tiers {
+		names = append(names, tier.Name)
+		thresholds = append(thresholds, tier.Threshold.String())
+		multipliers = append(multipliers, tier.Multiplier)
+	}
+
+	res, err := tp.db.ExecContext(ctx, recomputeTiers, names, thresholds, multipliers)
+	if err != nil {
+		return 0, fmt.Errorf("failed to recompute tiers: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+const getUserTier = `SELECT tier, multiplier, rolling_accrual, updated_at FROM user_tiers WHERE user_id = $1`
+
+// GetUserTier returns the zero tier with multiplier 1 for users that were not
+// recomputed yet.
+func (tp *TiersPostgres) GetUserTier(ctx context.Context, userID int) (models.UserTier, error) {
+	var tier models.UserTier
+	err := tp.db.QueryRowContext(ctx, getUserTier, userID).
+		Scan(&tier.Name, &tier.Multiplier, &tier.RollingAccrual, &tier.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.UserTier{Multiplier: 1}, nil
+		}
+		return models.UserTier{}, fmt.Errorf("failed to get user tier: %w", err)
+	}
+	return tier, nil
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+type TransfersPostgres struct {
+	db *sql.DB
+}
+
+func NewTransfersPostgres(db *sql.DB) *TransfersPostgres {
+	return &TransfersPostgres{db: db}
+}
+
+var (
+	ErrRecipientNotFound     = errors.New("recipient not found")
+	ErrSelfTransfer          = errors.New("can not transfer points to yourself")
+	ErrTransferLimitExceeded = errors.New("daily transfer limit exceeded")
+)
+
+const (
+	getRecipient = `SELECT id FROM users WHERE login = $1`
+
+	getSentToday = `
+				SELECT COALESCE(SUM(sum), 0), COUNT(*) FROM transfers
+				WHERE sender_id = $1 AND created_at >= date_trunc('day', NOW())`
+
+	creditBalanceOnTransfer = `
+				UPDATE balance SET current = current + $1, updated_at = NOW()
+				WHERE user_id = $2
+				RETURNING current`
+
+	debitBalanceOnTransfer = `
+				UPDATE balance SET current = current - $1, updated_at = NOW()
+				WHERE user_id = $2 AND current - held >= $1
+				RETURNING current`
+
+	// transferPointLots takes the sum from the sender lots the same way as
+	// consumePointLots and gives it to the recipient keeping the expiry, so
+	// transfers never extend the life of points.
+	// Arguments: sender id, sum, recipient id.
+	transferPointLots = `
+				WITH locked AS (
+					SELECT id, remaining, expires_at FROM point_lots
+					WHERE user_id = $1 AND remaining > 0
+					ORDER BY expires_at, id
+					FOR UPDATE
+				), ordered AS (
+					SELECT id, remaining,
+						SUM(remaining) OVER (ORDER BY expires_at, id) - remaining AS taken_before
+					FROM locked
+				), taken AS (
+					UPDATE point_lots p
+					SET remaining = p.remaining - LEAST(o.remaining, $2::NUMERIC - o.taken_before)
+					FROM ordered o
+					WHERE p.id = o.id AND o.taken_before < $2::NUMERIC
+					RETURNING p.earned_at, p.expires_at, LEAST(o.remaining, $2::NUMERIC - o.taken_before) AS amount
+				)
+				INSERT INTO point_lots (user_id, amount, remaining, earned_at, expires_at)
+				SELECT $3, amount, amount, earned_at, expires_at FROM taken WHERE amount > 0`
+
+	insertTransfer = `
+				INSERT INTO transfers (sender_id, recipient_id, sum, note)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id, created_at`
+
+	// postTransferEntries arguments: sender id, recipient id, sum, sender
+	// balance after, recipient balance after.
+	postTransferEntries = `
+				WITH tx AS (SELECT nextval('ledger_transaction_seq') AS id)
+				INSERT INTO ledger_entries (transaction_id, account, user_id, entry_type, amount, balance_after)
+				SELECT tx.id, 'user:' || $1::INTEGER, $1::INTEGER, 'transfer', -$3::NUMERIC, $4::NUMERIC FROM tx
+				UNION ALL
+				SELECT tx.id, 'user:' || $2::INTEGER, $2::INTEGER, 'transfer', $3::NUMERIC, $5::NUMERIC FROM tx`
+)
+
+// CreateTransfer moves points between users in a serializable transaction,
+// callers are expected to retry on serialization failures. The sent points
+// count against the withdrawal limits of the sender as well.
+func (tp *TransfersPostgres) CreateTransfer(ctx context.Context, senderID int, req models.TransferRequest,
+	limits models.TransferLimits, withdrawalLimits models.WithdrawalLimits) (models.Transfer, error) {
+	tx, err := tp.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var recipientID int
+	if err := tx.QueryRowContext(ctx, getRecipient, req.Recipient).Scan(&recipientID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Transfer{}, ErrRecipientNotFound
+		}
+		return models.Transfer{}, fmt.Errorf("failed to get recipient: %w", err)
+	}
+	if recipientID == senderID {
+		return models.Transfer{}, ErrSelfTransfer
+	}
+
+	if limits.DailySum > 0 || limits.DailyCount > 0 {
+		var sentSum models.Money
+		var sentCount int
+		if err := tx.QueryRowContext(ctx, getSentToday, senderID).Scan(&sentSum, &sentCount); err != nil {
+			return models.Transfer{}, fmt.Errorf("failed to get sent transfers: %w", err)
+		}
+		if limits.DailySum > 0 && sentSum+req.Sum > limits.DailySum {
+			return models.Transfer{}, ErrTransferLimitExceeded
+		}
+		if limits.DailyCount > 0 && sentCount >= limits.DailyCount {
+			return models.Transfer{}, ErrTransferLimitExceeded
+		}
+	}
+
+	for _, userID := range []int{senderID, recipientID} {
+		if _, err := tx.ExecContext(ctx, insertBalanceIfNotExists, userID); err != nil {
+			return models.Transfer{}, fmt.Errorf("failed to insert balance row: %w", err)
+		}
+	}
+
+	if err := checkWithdrawalLimits(ctx, tx, senderID, req.Sum, withdrawalLimits); err != nil {
+		return models.Transfer{}, err
+	}
+
+	var senderCurrent, recipientCurrent models.Money
+	err = tx.QueryRowContext(ctx, debitBalanceOnTransfer, req.Sum, senderID).Scan(&senderCurrent)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Transfer{}, ErrInsufficientBalance
+		}
+		return models.Transfer{}, fmt.Errorf("failed to debit sender: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, creditBalanceOnTransfer, req.Sum, recipientID).Scan(&recipientCurrent)
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to credit recipient: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, transferPointLots, senderID, req.Sum, recipientID); err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to transfer point lots: %w", err)
+	}
+
+	transfer := models.Transfer{
+		Direction:    models.TransferOut,
+		Counterparty: req.Recipient,
+		Sum:          req.Sum,
+		Note:         req.Note,
+	}
+	err = tx.QueryRowContext(ctx, insertTransfer, senderID, recipientID, req.Sum, req.Note).
+		Scan(&transfer.ID, &transfer.CreatedAt)
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to insert transfer: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, postTransferEntries, senderID, recipientID, req.Sum, senderCurrent, recipientCurrent)
+	if err != nil {
+		return models.Transfer{}, fmt.Errorf("failed to post ledger entries: %w", err)
+	}
+
+	return transfer, tx.Commit()
+}
+
+const getTransfers = `
+				SELECT t.id,
+					CASE WHEN t.sender_id = $1 THEN 'out' ELSE 'in' END,
+					u.login, t.sum, t.note, t.created_at
+				FROM transfers t
+				JOIN users u ON u.id = CASE WHEN t.sender_id = $1 THEN t.recipient_id ELSE t.sender_id END
+				WHERE (t.sender_id = $1 OR t.recipient_id = $1)
+					AND ($2::TIMESTAMP IS NULL OR (t.created_at, t.id) < ($2, $3::BIGINT))
+				ORDER BY t.created_at DESC, t.id DESC
+				LIMIT NULLIF($4::INTEGER, 0)`
+
+func (tp *TransfersPostgres) ListTransfers(ctx context.Context, userID int, page models.Page) ([]models.Transfer, error) {
+	var cursorAt, cursorID any
+	if page.After != nil {
+		cursorAt, cursorID = page.After.At, page.After.ID
+	}
+
+	rows, err := tp.db.QueryContext(ctx, getTransfers, userID, cursorAt, cursorID, page.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := make([]models.Transfer, 0)
+	for rows.Next() {
+		var t models.Transfer
+		if err := rows.Scan(&t.ID, &t.Direction, &t.Counterparty, &t.Sum, &t.Note, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
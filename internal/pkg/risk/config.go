@@ -0,0 +1,96 @@
+package risk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+// Config enables signals with a positive weight. Events scoring FlagScore are
+// queued for review, BlockScore rejects them, zero disables an action.
+type Config struct {
+	FlagScore  int `json:"flag_score"`
+	BlockScore int `json:"block_score"`
+
+	UploadVelocity struct {
+		Window     models.Duration `json:"window"`
+		MaxUploads int             `json:"max_uploads"`
+		Weight     int             `json:"weight"`
+	} `json:"upload_velocity"`
+
+	InvalidRatio struct {
+		MinOrders int     `json:"min_orders"`
+		MaxRatio  float64 `json:"max_ratio"`
+		Weight    int     `json:"weight"`
+	} `json:"invalid_ratio"`
+
+	NewAccount struct {
+		MinAge models.Duration `json:"min_age"`
+		Weight int             `json:"weight"`
+	} `json:"new_account"`
+
+	IPReuse struct {
+		MaxAccounts int `json:"max_accounts"`
+		Weight      int `json:"weight"`
+	} `json:"ip_reuse"`
+}
+
+// LoadConfig reads a JSON file like
+// {"flag_score": 50, "block_score": 100, "upload_velocity": {"window": "1h", "max_uploads": 30, "weight": 50}, ...}.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read risk config: %w", err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to decode risk config: %w", err)
+	}
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	if c.FlagScore < 0 || c.BlockScore < 0 {
+		return fmt.Errorf("risk scores must not be negative")
+	}
+	if c.FlagScore > 0 && c.BlockScore > 0 && c.FlagScore > c.BlockScore {
+		return fmt.Errorf("flag score must not exceed block score")
+	}
+	if c.UploadVelocity.Weight > 0 && c.UploadVelocity.Window.Duration <= 0 {
+		return fmt.Errorf("upload velocity needs a window")
+	}
+	if c.InvalidRatio.Weight > 0 && (c.InvalidRatio.MaxRatio <= 0 || c.InvalidRatio.MaxRatio >= 1) {
+		return fmt.Errorf("invalid ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// VelocityWindow is the period recent uploads are counted in.
+func (c Config) VelocityWindow() time.Duration {
+	return c.UploadVelocity.Window.Duration
+}
+
+func (c Config) signals() []Signal {
+	var signals []Signal
+	if c.UploadVelocity.Weight > 0 {
+		signals = append(signals, UploadVelocity{Max: c.UploadVelocity.MaxUploads, Weight: c.UploadVelocity.Weight})
+	}
+	if c.InvalidRatio.Weight > 0 {
+		signals = append(signals, InvalidRatio{
+			MinOrders: c.InvalidRatio.MinOrders,
+			MaxRatio:  c.InvalidRatio.MaxRatio,
+			Weight:    c.InvalidRatio.Weight,
+		})
+	}
+	if c.NewAccount.Weight > 0 {
+		signals = append(signals, NewAccount{MinAge: c.NewAccount.MinAge.Duration, Weight: c.NewAccount.Weight})
+	}
+	if c.IPReuse.Weight > 0 {
+		signals = append(signals, IPReuse{Max: c.IPReuse.MaxAccounts, Weight: c.IPReuse.Weight})
+	}
+	return signals
+}
@@ -0,0 +1,48 @@
+// Package risk scores order uploads and withdrawals by signals computed from
+// the account history and maps the score to an action.
+package risk
+
+import (
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+// Signal contributes to the risk score of an event, it returns the points it
+// adds and whether it fired at all.
+type Signal interface {
+	Name() string
+	Evaluate(event models.RiskEvent, stats models.RiskStats) (int, bool)
+}
+
+// Scorer runs the signals and picks the action for the total score.
+type Scorer struct {
+	signals    []Signal
+	flagScore  int
+	blockScore int
+}
+
+// NewScorer builds the signals enabled in cfg followed by extra ones.
+func NewScorer(cfg Config, extra ...Signal) *Scorer {
+	signals := cfg.signals()
+	signals = append(signals, extra...)
+	return &Scorer{signals: signals, flagScore: cfg.FlagScore, blockScore: cfg.BlockScore}
+}
+
+func (s *Scorer) Score(event models.RiskEvent, stats models.RiskStats) models.RiskDecision {
+	decision := models.RiskDecision{Action: models.RiskActionAllow, Reasons: make([]string, 0)}
+	for _, signal := range s.signals {
+		score, fired := signal.Evaluate(event, stats)
+		if !fired {
+			continue
+		}
+		decision.Score += score
+		decision.Reasons = append(decision.Reasons, signal.Name())
+	}
+
+	switch {
+	case s.blockScore > 0 && decision.Score >= s.blockScore:
+		decision.Action = models.RiskActionBlock
+	case s.flagScore > 0 && decision.Score >= s.flagScore:
+		decision.Action = models.RiskActionFlag
+	}
+	return decision
+}
@@ -0,0 +1,123 @@
+package risk
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `{
+	"flag_score": 40,
+	"block_score": 80,
+	"upload_velocity": {"window": "1h", "max_uploads": 30, "weight": 50},
+	"invalid_ratio": {"min_orders": 10, "max_ratio": 0.5, "weight": 40},
+	"new_account": {"min_age": "24h", "weight": 20},
+	"ip_reuse": {"max_accounts": 2, "weight": 30}
+}`
+
+func loadTestConfig(t *testing.T) Config {
+	path := filepath.Join(t.TempDir(), "risk.json")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0o600))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestScorer(t *testing.T) {
+	cfg := loadTestConfig(t)
+	assert.Equal(t, time.Hour, cfg.VelocityWindow())
+
+	scorer := NewScorer(cfg)
+	event := models.RiskEvent{Kind: models.RiskEventOrderUpload, UserID: 1, Order: 12345678903}
+	established := models.RiskStats{Orders: 20, InvalidOrders: 2, AccountAge: 30 * 24 * time.Hour}
+
+	tests := []struct {
+		name    string
+		stats   func(models.RiskStats) models.RiskStats
+		score   int
+		action  string
+		reasons []string
+	}{
+		{
+			name:    "established account",
+			stats:   func(s models.RiskStats) models.RiskStats { return s },
+			action:  models.RiskActionAllow,
+			reasons: []string{},
+		},
+		{
+			name: "fresh account sharing an address",
+			stats: func(s models.RiskStats) models.RiskStats {
+				s.AccountAge, s.AccountsSameIP = time.Hour, 3
+				return s
+			},
+			score:   50,
+			action:  models.RiskActionFlag,
+			reasons: []string{"new_account", "ip_reuse"},
+		},
+		{
+			name: "bogus uploads",
+			stats: func(s models.RiskStats) models.RiskStats {
+				s.RecentUploads, s.InvalidOrders = 100, 15
+				return s
+			},
+			score:   90,
+			action:  models.RiskActionBlock,
+			reasons: []string{"upload_velocity", "invalid_ratio"},
+		},
+		{
+			name: "too few orders to judge the ratio",
+			stats: func(s models.RiskStats) models.RiskStats {
+				s.Orders, s.InvalidOrders = 5, 5
+				return s
+			},
+			action:  models.RiskActionAllow,
+			reasons: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := scorer.Score(event, tt.stats(established))
+			assert.Equal(t, tt.score, decision.Score)
+			assert.Equal(t, tt.action, decision.Action)
+			assert.Equal(t, tt.reasons, decision.Reasons)
+		})
+	}
+}
+
+type staticSignal struct{ weight int }
+
+func (s staticSignal) Name() string { return "static" }
+
+func (s staticSignal) Evaluate(models.RiskEvent, models.RiskStats) (int, bool) { return s.weight, true }
+
+func TestScorerExtraSignals(t *testing.T) {
+	scorer := NewScorer(Config{BlockScore: 10}, staticSignal{weight: 10})
+	decision := scorer.Score(models.RiskEvent{Kind: models.RiskEventWithdrawal}, models.RiskStats{})
+	assert.Equal(t, models.RiskActionBlock, decision.Action)
+	assert.Equal(t, []string{"static"}, decision.Reasons)
+}
+
+func TestConfigValidate(t *testing.T) {
+	var cfg Config
+	require.NoError(t, json.Unmarshal([]byte(testConfig), &cfg))
+	assert.NoError(t, cfg.Validate())
+
+	cfg.FlagScore = 100
+	assert.Error(t, cfg.Validate())
+
+	cfg = Config{}
+	cfg.InvalidRatio.Weight, cfg.InvalidRatio.MaxRatio = 10, 1.5
+	assert.Error(t, cfg.Validate())
+
+	cfg = Config{}
+	cfg.UploadVelocity.Weight = 10
+	assert.Error(t, cfg.Validate())
+}
@@ -0,0 +1,62 @@
+package risk
+
+import (
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+// UploadVelocity fires when the user uploaded more than Max orders within the
+// stats window.
+type UploadVelocity struct {
+	Max    int
+	Weight int
+}
+
+func (s UploadVelocity) Name() string { return "upload_velocity" }
+
+func (s UploadVelocity) Evaluate(_ models.RiskEvent, stats models.RiskStats) (int, bool) {
+	return s.Weight, stats.RecentUploads > s.Max
+}
+
+// InvalidRatio fires when more than MaxRatio of the user's orders turned out
+// invalid, users with fewer than MinOrders orders are not judged.
+type InvalidRatio struct {
+	MinOrders int
+	MaxRatio  float64
+	Weight    int
+}
+
+func (s InvalidRatio) Name() string { return "invalid_ratio" }
+
+func (s InvalidRatio) Evaluate(_ models.RiskEvent, stats models.RiskStats) (int, bool) {
+	if stats.Orders == 0 || stats.Orders < s.MinOrders {
+		return 0, false
+	}
+	return s.Weight, float64(stats.InvalidOrders)/float64(stats.Orders) > s.MaxRatio
+}
+
+// NewAccount fires for accounts younger than MinAge.
+type NewAccount struct {
+	MinAge time.Duration
+	Weight int
+}
+
+func (s NewAccount) Name() string { return "new_account" }
+
+func (s NewAccount) Evaluate(_ models.RiskEvent, stats models.RiskStats) (int, bool) {
+	return s.Weight, stats.AccountAge < s.MinAge
+}
+
+// IPReuse fires when more than Max other accounts were registered from the
+// address of the user.
+type IPReuse struct {
+	Max    int
+	Weight int
+}
+
+func (s IPReuse) Name() string { return "ip_reuse" }
+
+func (s IPReuse) Evaluate(_ models.RiskEvent, stats models.RiskStats) (int, bool) {
+	return s.Weight, stats.AccountsSameIP > s.Max
+}
@@ -0,0 +1,12 @@
+package service
+
+import (
+	"context"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+)
+
+// AccrualRules is implemented by the local accrual engine.
+type AccrualRules interface {
+	Simulate(ctx context.Context, order models.AccrualSimulation) (models.AccrualSimulationResult, error)
+}
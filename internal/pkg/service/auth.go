@@ -15,6 +15,7 @@ type Authorization interface {
 	CreateUser(ctx context.Context, user models.User) (int, error)
 	GenerateToken(ctx context.Context, login, password string) (string, error)
 	ParseToken(ctx context.Context, tokenGot string) (int, error)
+	ChangePassword(ctx context.Context, userID int, req models.ChangePasswordRequest) error
 }
 
 const (
@@ -36,16 +37,24 @@ type tokenClaims struct {
 }
 
 type AuthService struct {
-	repo repository.AuthorizationRepository
+	repo    repository.AuthorizationRepository
+	rewards models.ReferralRewards
 }
 
-func NewAuthService(repo repository.AuthorizationRepository) *AuthService {
-	return &AuthService{repo: repo}
+func NewAuthService(repo repository.AuthorizationRepository, rewards models.ReferralRewards) *AuthService {
+	return &AuthService{repo: repo, rewards: rewards}
 }
 
 func (as *AuthService) CreateUser(ctx context.Context, user models.User) (int, error) {
 	user.Password = generateHash(user.Password)
-	return as.repo.CreateUser(ctx, user)
+	return as.repo.CreateUser(ctx, user, as.rewards)
+}
+
+func (as *AuthService) ChangePassword(ctx context.Context, userID int, req models.ChangePasswordRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	return as.repo.UpdatePassword(ctx, userID, generateHash(req.OldPassword), generateHash(req.NewPassword))
 }
 
 func (as *AuthService) GenerateToken(ctx context.Context, login, password string) (string, error) {
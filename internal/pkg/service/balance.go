@@ -10,25 +10,57 @@ import (
 type Balance interface {
 	DisplayUserBalance(ctx context.Context, userID int) (models.Balance, error)
 	WithdrawLoyaltyPoints(ctx context.Context, userID int, withdraw models.WithdrawRequest) error
-	DisplayWithdrawals(ctx context.Context, userID int) ([]models.Withdrawal, error)
+	StreamWithdrawals(ctx context.Context, userID int, filter models.WithdrawalFilter, fn func(models.Withdrawal) error) error
+	ReverseWithdrawal(ctx context.Context, order int64, reversal models.ReversalRequest) (models.Withdrawal, error)
+	DisplayStatement(ctx context.Context, userID int, cursor int64, limit int) (models.Statement, error)
 }
 
 type BalanceService struct {
-	repo repository.BalanceRepository
+	repo    repository.BalanceRepository
+	limits  models.WithdrawalLimits
+	accrual models.WithdrawalAccrualPolicy
+	risk    RiskAssessor
 }
 
-func NewBalanceService(repo repository.BalanceRepository) *BalanceService {
-	return &BalanceService{repo: repo}
+// NewBalanceService takes a nil risk when risk checks are disabled.
+func NewBalanceService(repo repository.BalanceRepository, limits models.WithdrawalLimits,
+	accrual models.WithdrawalAccrualPolicy, risk RiskAssessor) *BalanceService {
+	return &BalanceService{repo: repo, limits: limits, accrual: accrual, risk: risk}
 }
 
 func (bs *BalanceService) DisplayUserBalance(ctx context.Context, userID int) (models.Balance, error) {
 	return bs.repo.DisplayUserBalance(ctx, userID)
 }
 
+// WithdrawLoyaltyPoints rejects withdrawals breaking the limits with a
+// *models.LimitError, limits depending on past withdrawals are checked by the
+// repository within the withdrawal transaction. Whether the order is also
+// registered for accrual follows the configured policy.
 func (bs *BalanceService) WithdrawLoyaltyPoints(ctx context.Context, userID int, withdraw models.WithdrawRequest) error {
-	return bs.repo.WithdrawLoyaltyPoints(ctx, userID, withdraw)
+	if err := bs.limits.CheckSum(withdraw.Sum); err != nil {
+		return err
+	}
+
+	if bs.risk != nil {
+		event := models.RiskEvent{Kind: models.RiskEventWithdrawal, UserID: userID, Order: withdraw.Order, Sum: withdraw.Sum}
+		if err := bs.risk.Assess(ctx, event); err != nil {
+			return err
+		}
+	}
+	return bs.repo.WithdrawLoyaltyPoints(ctx, userID, withdraw, bs.limits, bs.accrual)
+}
+
+func (bs *BalanceService) StreamWithdrawals(ctx context.Context, userID int, filter models.WithdrawalFilter, fn func(models.Withdrawal) error) error {
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	return bs.repo.StreamWithdrawals(ctx, userID, filter, fn)
+}
+
+func (bs *BalanceService) ReverseWithdrawal(ctx context.Context, order int64, reversal models.ReversalRequest) (models.Withdrawal, error) {
+	return bs.repo.ReverseWithdrawal(ctx, order, reversal)
 }
 
-func (bs *BalanceService) DisplayWithdrawals(ctx context.Context, userID int) ([]models.Withdrawal, error) {
-	return bs.repo.DisplayWithdrawals(ctx, userID)
+func (bs *BalanceService) DisplayStatement(ctx context.Context, userID int, cursor int64, limit int) (models.Statement, error) {
+	return bs.repo.GetStatement(ctx, userID, cursor, limit)
 }
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+)
+
+type Campaigns interface {
+	CreateCampaign(ctx context.Context, c models.Campaign) (models.Campaign, error)
+	UpdateCampaign(ctx context.Context, c models.Campaign) (models.Campaign, error)
+	GetCampaign(ctx context.Context, id int) (models.Campaign, error)
+	ListCampaigns(ctx context.Context) ([]models.Campaign, error)
+	DeleteCampaign(ctx context.Context, id int) error
+	ListBonuses(ctx context.Context, campaignID int) ([]models.CampaignBonus, error)
+	ReverseBonus(ctx context.Context, bonusID int64) (models.CampaignBonus, error)
+}
+
+// CampaignsService manages campaign definitions, bonuses are credited by the
+// order processing when an order becomes PROCESSED.
+type CampaignsService struct {
+	repo repository.CampaignsRepository
+}
+
+func NewCampaignsService(repo repository.CampaignsRepository) *CampaignsService {
+	return &CampaignsService{repo: repo}
+}
+
+func (cs *CampaignsService) CreateCampaign(ctx context.Context, c models.Campaign) (models.Campaign, error) {
+	if err := c.Validate(); err != nil {
+		return models.Campaign{}, err
+	}
+	return cs.repo.CreateCampaign(ctx, c)
+}
+
+func (cs *CampaignsService) UpdateCampaign(ctx context.Context, c models.Campaign) (models.Campaign, error) {
+	if err := c.Validate(); err != nil {
+		return models.Campaign{}, err
+	}
+	return cs.repo.UpdateCampaign(ctx, c)
+}
+
+func (cs *CampaignsService) GetCampaign(ctx context.Context, id int) (models.Campaign, error) {
+	return cs.repo.GetCampaign(ctx, id)
+}
+
+func (cs *CampaignsService) ListCampaigns(ctx context.Context) ([]models.Campaign, error) {
+	return cs.repo.ListCampaigns(ctx)
+}
+
+func (cs *CampaignsService) DeleteCampaign(ctx context.Context, id int) error {
+	return cs.repo.DeleteCampaign(ctx, id)
+}
+
+func (cs *CampaignsService) ListBonuses(ctx context.Context, campaignID int) ([]models.CampaignBonus, error) {
+	if _, err := cs.repo.GetCampaign(ctx, campaignID); err != nil {
+		return nil, err
+	}
+	return cs.repo.ListBonuses(ctx, campaignID)
+}
+
+func (cs *CampaignsService) ReverseBonus(ctx context.Context, bonusID int64) (models.CampaignBonus, error) {
+	return cs.repo.ReverseBonus(ctx, bonusID)
+}
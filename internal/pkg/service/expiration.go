@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+)
+
+type PointsExpirationService struct {
+	repo repository.PointLotsRepository
+}
+
+func NewPointsExpirationService(repo repository.PointLotsRepository) *PointsExpirationService {
+	return &PointsExpirationService{repo: repo}
+}
+
+// ExpirePoints writes off expired points of every user and reports how many
+// users were affected and how many points expired in total.
+func (ps *PointsExpirationService) ExpirePoints(ctx context.Context) (int, models.Money, error) {
+	users, err := ps.repo.UsersWithExpiredLots(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var affected int
+	var total models.Money
+	for _, userID := range users {
+		expired, err := ps.repo.ExpireUserPoints(ctx, userID)
+		if err != nil {
+			logger.Log.Sugar().Errorf("failed to expire points of user %d: %v", userID, err)
+			continue
+		}
+		if expired > 0 {
+			affected++
+			total += expired
+		}
+	}
+
+	return affected, total, nil
+}
+
+// Run expires points every interval until ctx is done.
+func (ps *PointsExpirationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			affected, total, err := ps.ExpirePoints(ctx)
+			if err != nil {
+				logger.Log.Sugar().Errorf("points expiration failed: %v", err)
+				continue
+			}
+			if affected > 0 {
+				logger.Log.Sugar().Infof("points expiration: %s points of %d users expired", total, affected)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+)
+
+type Holds interface {
+	CreateHold(ctx context.Context, userID int, req models.HoldRequest) (models.Hold, error)
+	CaptureHold(ctx context.Context, userID int, holdID int64, capture models.WithdrawRequest) (models.Hold, error)
+	ReleaseHold(ctx context.Context, userID int, holdID int64) (models.Hold, error)
+}
+
+// MaxHoldTTL limits how long a checkout may keep points reserved.
+const MaxHoldTTL = models.MaxHoldTTL
+
+var ErrHoldTTLTooLong = models.ErrHoldTTLTooLong
+
+type HoldsService struct {
+	repo    repository.HoldsRepository
+	ttl     time.Duration
+	limits  models.WithdrawalLimits
+	accrual models.WithdrawalAccrualPolicy
+	risk    RiskAssessor
+}
+
+// NewHoldsService takes a nil risk when risk checks are disabled.
+func NewHoldsService(repo repository.HoldsRepository, ttl time.Duration, limits models.WithdrawalLimits,
+	accrual models.WithdrawalAccrualPolicy, risk RiskAssessor) *HoldsService {
+	return &HoldsService{repo: repo, ttl: ttl, limits: limits, accrual: accrual, risk: risk}
+}
+
+func (hs *HoldsService) CreateHold(ctx context.Context, userID int, req models.HoldRequest) (models.Hold, error) {
+	if err := req.Validate(); err != nil {
+		return models.Hold{}, err
+	}
+
+	ttl := hs.ttl
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+	if ttl > MaxHoldTTL {
+		return models.Hold{}, ErrHoldTTLTooLong
+	}
+
+	return hs.repo.CreateHold(ctx, userID, req.Sum, ttl)
+}
+
+func (hs *HoldsService) CaptureHold(ctx context.Context, userID int, holdID int64, capture models.WithdrawRequest) (models.Hold, error) {
+	if capture.Sum > 0 {
+		if err := hs.limits.CheckSum(capture.Sum); err != nil {
+			return models.Hold{}, err
+		}
+	}
+
+	if hs.risk != nil {
+		event := models.RiskEvent{Kind: models.RiskEventHoldCapture, UserID: userID, Order: capture.Order, Sum: capture.Sum}
+		if err := hs.risk.Assess(ctx, event); err != nil {
+			return models.Hold{}, err
+		}
+	}
+
+	return hs.repo.CaptureHold(ctx, userID, holdID, capture, hs.limits, hs.accrual)
+}
+
+func (hs *HoldsService) ReleaseHold(ctx context.Context, userID int, holdID int64) (models.Hold, error) {
+	return hs.repo.ReleaseHold(ctx, userID, holdID)
+}
+
+// Run releases expired holds every interval until ctx is done.
+func (hs *HoldsService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expired, err := hs.repo.ExpireHolds(ctx)
+			if err != nil {
+				logger.Log.Sugar().Errorf("failed to expire holds: %v", err)
+				continue
+			}
+			if expired > 0 {
+				logger.Log.Sugar().Infof("released %d expired holds", expired)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+)
+
+type Idempotency interface {
+	Begin(ctx context.Context, userID int, key, requestHash string) (*models.IdempotencyRecord, error)
+	Complete(ctx context.Context, userID int, key string, record models.IdempotencyRecord) error
+	Release(ctx context.Context, userID int, key string) error
+}
+
+var (
+	ErrIdempotencyKeyReused    = errors.New("idempotency key was used with a different request")
+	ErrIdempotencyKeyInProcess = errors.New("request with this idempotency key is in progress")
+)
+
+type IdempotencyService struct {
+	repo repository.IdempotencyRepository
+	ttl  time.Duration
+}
+
+func NewIdempotencyService(repo repository.IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// Begin reserves the key for the request, a nil record means the caller has to
+// process the request, otherwise the stored response must be replayed.
+func (is *IdempotencyService) Begin(ctx context.Context, userID int, key, requestHash string) (*models.IdempotencyRecord, error) {
+	acquired, record, err := is.repo.AcquireKey(ctx, userID, key, requestHash, is.ttl)
+	if err != nil {
+		return nil, err
+	}
+	if acquired {
+		return nil, nil
+	}
+
+	if record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !record.Completed() {
+		return nil, ErrIdempotencyKeyInProcess
+	}
+	return &record, nil
+}
+
+func (is *IdempotencyService) Complete(ctx context.Context, userID int, key string, record models.IdempotencyRecord) error {
+	return is.repo.CompleteKey(ctx, userID, key, record.StatusCode, record.ContentType, record.Body)
+}
+
+func (is *IdempotencyService) Release(ctx context.Context, userID int, key string) error {
+	return is.repo.ReleaseKey(ctx, userID, key)
+}
+
+// Run removes expired keys every interval until ctx is done.
+func (is *IdempotencyService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := is.repo.PurgeExpiredKeys(ctx)
+			if err != nil {
+				logger.Log.Sugar().Errorf("failed to purge idempotency keys: %v", err)
+				continue
+			}
+			if purged > 0 {
+				logger.Log.Sugar().Infof("purged %d expired idempotency keys", purged)
+			}
+		}
+	}
+}
@@ -12,12 +12,13 @@ import (
 
 type Order interface {
 	CreateOrder(ctx context.Context, order models.Order) (*ResponseInfo, error)
-	ListOrders(ctx context.Context, userID int) ([]models.Order, error)
+	ListOrders(ctx context.Context, userID int, page models.Page) ([]models.Order, error)
 	CheckOrderStatus(ctx context.Context, orderID int64, userID int) (string, error)
 }
 
 type OrderService struct {
 	repo repository.OrderRepository
+	risk RiskAssessor
 }
 
 type OrderServiceError struct {
@@ -29,12 +30,17 @@ func (oe *OrderServiceError) Error() string {
 	return oe.ErrMsg.Error()
 }
 
+func (oe *OrderServiceError) Unwrap() error {
+	return oe.ErrMsg
+}
+
 type ResponseInfo struct {
 	RespStatusCode int
 }
 
-func NewOrderService(repo repository.OrderRepository) *OrderService {
-	return &OrderService{repo: repo}
+// NewOrderService takes a nil risk when risk checks are disabled.
+func NewOrderService(repo repository.OrderRepository, risk RiskAssessor) *OrderService {
+	return &OrderService{repo: repo, risk: risk}
 }
 
 func (os *OrderService) CreateOrder(ctx context.Context, order models.Order) (*ResponseInfo, error) {
@@ -53,6 +59,16 @@ func (os *OrderService) CreateOrder(ctx context.Context, order models.Order) (*R
 		}
 
 	case repository.StatusNotExists:
+		if os.risk != nil {
+			event := models.RiskEvent{Kind: models.RiskEventOrderUpload, UserID: order.UserID, Order: order.Number}
+			if err := os.risk.Assess(ctx, event); err != nil {
+				return nil, &OrderServiceError{
+					RespStatusCode: http.StatusForbidden,
+					ErrMsg:         err,
+				}
+			}
+		}
+
 		err := os.repo.CreateOrder(ctx, order)
 		if err != nil {
 			if errors.Is(err, repository.ErrAlreadyExists) {
@@ -76,8 +92,8 @@ func (os *OrderService) CreateOrder(ctx context.Context, order models.Order) (*R
 	}
 }
 
-func (os *OrderService) ListOrders(ctx context.Context, userID int) ([]models.Order, error) {
-	return os.repo.ListOrders(ctx, userID)
+func (os *OrderService) ListOrders(ctx context.Context, userID int, page models.Page) ([]models.Order, error) {
+	return os.repo.ListOrders(ctx, userID, page)
 }
 
 func (os *OrderService) CheckOrderStatus(ctx context.Context, orderID int64, userID int) (string, error) {
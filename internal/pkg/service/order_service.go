@@ -2,16 +2,14 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
-	"strconv"
 	"sync"
 	"time"
 
 	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/accrual"
 	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -26,34 +24,42 @@ type OrderProcessing interface {
 
 type OrderProcessingService struct {
 	repo         repository.WorkerPoolRepository
-	accrualAddr  string
+	providers    *accrual.Registry
 	pool         *pgxpool.Pool
 	channel      string
 	workers      int
 	cancel       context.CancelFunc
-	client       *http.Client
 	ordersQueue  chan int64
 	updatesQueue chan models.Order
 	workersWg    sync.WaitGroup
 	orderLocks   *sync.Map
 }
 
-func NewOrderProcessingService(pool *pgxpool.Pool, accrualAddr string, channel string) (*OrderProcessingService, error) {
-	client := &http.Client{
-		Transport: &http.Transport{
-			MaxIdleConns:        50,
-			MaxIdleConnsPerHost: 20,
-			IdleConnTimeout:     60 * time.Second,
-		},
-		Timeout: 15 * time.Second,
+type ProcessingOption func(*processingOptions)
+
+type processingOptions struct {
+	tierMultipliers bool
+}
+
+// WithTierMultipliers multiplies accruals by the multiplier of the user tier,
+// it is meant for running with loyalty tiers enabled.
+func WithTierMultipliers() ProcessingOption {
+	return func(o *processingOptions) {
+		o.tierMultipliers = true
+	}
+}
+
+func NewOrderProcessingService(pool *pgxpool.Pool, providers *accrual.Registry, channel string, opts ...ProcessingOption) (*OrderProcessingService, error) {
+	var options processingOptions
+	for _, opt := range opts {
+		opt(&options)
 	}
 
 	return &OrderProcessingService{
-		repo:         repository.NewWorkerPoolRepo(pool),
-		accrualAddr:  accrualAddr,
+		repo:         repository.NewWorkerPoolRepo(pool, options.tierMultipliers),
+		providers:    providers,
 		pool:         pool,
 		channel:      channel,
-		client:       client,
 		ordersQueue:  make(chan int64, 1000),
 		updatesQueue: make(chan models.Order, 1000),
 		workersWg:    sync.WaitGroup{},
@@ -106,21 +112,51 @@ func (s *OrderProcessingService) notificationListener(ctx context.Context) {
 				continue
 			}
 
-			var orderNumber int64
-			if _, err := fmt.Sscan(notification.Payload, &orderNumber); err != nil {
-				logger.Log.Sugar().Errorf("Failed to parse order number: %v", err)
-				continue
-			}
+			logger.Log.Sugar().Debugf("outbox notification for order %s", notification.Payload)
+			s.drainOutbox(ctx)
+		}
+	}
+}
 
-			select {
-			case s.ordersQueue <- orderNumber:
-			default:
-				logger.Log.Sugar().Warnf("queue is full, drop order %d", orderNumber)
-			}
+// outboxLease is how long a claimed outbox job is kept from other consumers,
+// a job not completed by then is claimed again.
+const outboxLease = time.Minute
+
+// drainOutbox claims pending outbox jobs and queues their orders.
+func (s *OrderProcessingService) drainOutbox(ctx context.Context) {
+	const batchSize = 100
+
+	for {
+		numbers, err := s.repo.ClaimOutbox(ctx, batchSize, outboxLease)
+		if err != nil {
+			logger.Log.Sugar().Errorf("Failed to claim outbox jobs: %v", err)
+			return
+		}
+
+		for _, number := range numbers {
+			s.enqueue(ctx, number)
+		}
+
+		if len(numbers) < batchSize {
+			return
 		}
 	}
 }
 
+// enqueue queues the order unless it is already queued or being processed,
+// it waits for room in a full queue rather than dropping the order.
+func (s *OrderProcessingService) enqueue(ctx context.Context, orderNumber int64) {
+	if _, loaded := s.orderLocks.LoadOrStore(orderNumber, struct{}{}); loaded {
+		return
+	}
+
+	select {
+	case s.ordersQueue <- orderNumber:
+	case <-ctx.Done():
+		s.orderLocks.Delete(orderNumber)
+	}
+}
+
 func (s *OrderProcessingService) worker(ctx context.Context, workerID int) {
 	defer s.workersWg.Done()
 
@@ -129,10 +165,6 @@ func (s *OrderProcessingService) worker(ctx context.Context, workerID int) {
 		case <-ctx.Done():
 			return
 		case orderNumber := <-s.ordersQueue:
-			if _, loaded := s.orderLocks.LoadOrStore(orderNumber, struct{}{}); loaded {
-				continue
-			}
-
 			go func(order int64) {
 				defer s.orderLocks.Delete(order)
 
@@ -142,7 +174,7 @@ func (s *OrderProcessingService) worker(ctx context.Context, workerID int) {
 					return
 				}
 				if orderForUpdate.IsEmpty() {
-					logger.Log.Sugar().Infof("Worker %d: skipping empty order for %d", workerID, orderNumber)
+					logger.Log.Sugar().Infof("Worker %d: status of order %d has not changed", workerID, orderNumber)
 					return
 				}
 
@@ -161,7 +193,7 @@ func (s *OrderProcessingService) updateWorker(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case order := <-s.updatesQueue:
-			logger.Log.Sugar().Infof("updating with: number: %d, status: %s, accrual: %.2f", order.Number, order.Status, order.Accrual)
+			logger.Log.Sugar().Infof("updating with: number: %d, status: %s, accrual: %s", order.Number, order.Status, order.Accrual)
 
 			var lastErr error
 			for i := 0; i < 3; i++ {
@@ -197,6 +229,8 @@ func (s *OrderProcessingService) processExistingOrders(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			s.drainOutbox(ctx)
+
 			orders, err := s.repo.GetPendingOrders(ctx, batchSize)
 			if err != nil {
 				logger.Log.Sugar().Errorf("Failed to get pending orders: %v", err)
@@ -204,47 +238,67 @@ func (s *OrderProcessingService) processExistingOrders(ctx context.Context) {
 			}
 
 			for _, order := range orders {
-				select {
-				case s.ordersQueue <- order.Number:
-				default:
-					logger.Log.Sugar().Warnf("queue is full, skip %d", order.Number)
-				}
+				s.enqueue(ctx, order.Number)
 			}
 		}
 	}
 }
 
+var accrualStatusReasons = map[string]string{
+	models.OrderStatusRegistered: "order is registered in the accrual system",
+	models.OrderStatusProcessing: "accrual calculation is in progress",
+	models.OrderStatusInvalid:    "order was rejected by the accrual system",
+	models.OrderStatusProcessed:  "",
+}
+
 func (s *OrderProcessingService) processOrder(ctx context.Context, orderNumber int64) (models.Order, error) {
 	status, err := s.repo.LockAndGetOrderStatus(ctx, orderNumber)
 	if err != nil {
 		if errors.Is(err, repository.ErrOrderNotFound) {
-			status = "NEW"
+			status = models.OrderStatusNew
 		} else {
 			return models.Order{}, fmt.Errorf("failed to check order status: %w", err)
 		}
 	}
+	if (models.Order{Status: status}).IsFinal() {
+		if err := s.repo.CompleteOutbox(ctx, orderNumber); err != nil {
+			return models.Order{}, err
+		}
+		return models.Order{}, nil
+	}
 
-	accrualData, err := s.getAccrual(ctx, orderNumber)
+	accrualData, providerName, err := s.getAccrual(ctx, orderNumber)
 	if err != nil {
 		return models.Order{}, fmt.Errorf("failed to get data from accrual: %v", err)
 	}
 
-	if accrualData.Accrual == 0 {
-		logger.Log.Sugar().Infof("Order with number: %d has 0 loyalty points", accrualData.Order)
+	reason, ok := accrualStatusReasons[accrualData.Status]
+	if !ok {
+		return models.Order{}, fmt.Errorf("accrual service returned unknown status %q for order: %d", accrualData.Status, orderNumber)
 	}
 
-	if accrualData.Status == "INVALID" {
-		logger.Log.Sugar().Warnf("accrual service returned INVALID status for order: %d", orderNumber)
+	if accrualData.Status == status {
 		return models.Order{}, nil
 	}
 
+	if accrualData.Accrual == 0 {
+		logger.Log.Sugar().Infof("Order with number: %d has 0 loyalty points", accrualData.Order)
+	}
+
 	order := models.Order{
-		Number:  orderNumber,
-		Status:  accrualData.Status,
-		Accrual: accrualData.Accrual,
+		Number:       orderNumber,
+		Status:       accrualData.Status,
+		StatusReason: reason,
+		Accrual:      accrualData.Accrual,
+		Provider:     providerName,
 	}
 
-	if status == "NEW" {
+	if accrualData.Status == models.OrderStatusInvalid {
+		logger.Log.Sugar().Warnf("accrual service returned INVALID status for order: %d", orderNumber)
+		order.Accrual = 0
+	}
+
+	if status == models.OrderStatusNew {
 		err := s.insertMissingOrder(ctx, orderNumber)
 		if err != nil {
 			return models.Order{}, fmt.Errorf("failed to insert missing order: %w", err)
@@ -254,50 +308,27 @@ func (s *OrderProcessingService) processOrder(ctx context.Context, orderNumber i
 	return order, nil
 }
 
-func (s *OrderProcessingService) getAccrual(ctx context.Context, orderNumber int64) (*models.AccrualResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/orders/%d", s.accrualAddr, orderNumber), nil)
+func (s *OrderProcessingService) getAccrual(ctx context.Context, orderNumber int64) (*models.AccrualResponse, string, error) {
+	provider, err := s.providers.Resolve(orderNumber)
 	if err != nil {
-		return nil, fmt.Errorf("create request failed: %w", err)
+		return nil, "", err
 	}
 
-	resp, err := s.client.Do(req)
+	accrualData, err := provider.GetAccrual(ctx, orderNumber)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNoContent {
-		logger.Log.Sugar().Infof("accrual service returned 204 No Content for order: %d", orderNumber)
-		return nil, fmt.Errorf("accrual service returned 204 No Content")
+		return nil, provider.Name(), err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Log.Sugar().Errorf("accrual service returned status %d for order: %d", resp.StatusCode, orderNumber)
-		return nil, fmt.Errorf("accrual service returned status: %d", resp.StatusCode)
-	}
-
-	if resp.ContentLength == 0 {
-		logger.Log.Sugar().Warnf("accrual service returned empty body for order: %d", orderNumber)
-		return nil, fmt.Errorf("accrual response body is empty")
-	}
-
-	var accrualData models.AccrualResponse
-	if err := json.NewDecoder(resp.Body).Decode(&accrualData); err != nil {
-		logger.Log.Sugar().Errorf("Failed to decode accrual json data: %v", err)
-		return nil, err
-	}
-
-	accrualData.StatusCode = resp.StatusCode
-	return &accrualData, nil
+	return accrualData, provider.Name(), nil
 }
 
 func (s *OrderProcessingService) insertMissingOrder(ctx context.Context, orderNumber int64) error {
 	return s.repo.InsertMissingOrder(ctx, orderNumber)
 }
 
+// EnqueueOrder schedules an already stored order for processing once more
+// through the outbox, new orders are enqueued by OrderRepository.CreateOrder.
 func (s *OrderProcessingService) EnqueueOrder(ctx context.Context, order models.Order) error {
-	_, err := s.pool.Exec(ctx, `SELECT pg_notify($1, $2)`, s.channel, strconv.FormatInt(order.Number, 10))
-	return err
+	return s.repo.EnqueueOrder(ctx, order.Number)
 }
 
 func (s *OrderProcessingService) StopProcessing() {
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+)
+
+type Reconciliation interface {
+	Reconcile(ctx context.Context, fix bool) (models.ReconciliationReport, error)
+}
+
+type ReconciliationService struct {
+	repo repository.ReconciliationRepository
+}
+
+func NewReconciliationService(repo repository.ReconciliationRepository) *ReconciliationService {
+	return &ReconciliationService{repo: repo}
+}
+
+// Reconcile compares every balance with the one recomputed from the ledger
+// sources: processed orders, withdrawals, campaign bonuses, referral rewards,
+// lot expirations and transfers. With fix set the discrepancies are corrected.
+func (rs *ReconciliationService) Reconcile(ctx context.Context, fix bool) (models.ReconciliationReport, error) {
+	report := models.ReconciliationReport{CheckedAt: time.Now()}
+
+	checked, discrepancies, err := rs.repo.FindDiscrepancies(ctx)
+	if err != nil {
+		return report, err
+	}
+	report.UsersChecked = checked
+	report.Discrepancies = discrepancies
+
+	for i, d := range report.Discrepancies {
+		logger.Log.Sugar().Warnf("balance discrepancy for user %d: current %s, expected %s; withdrawn %s, expected %s",
+			d.UserID, d.ActualCurrent, d.ExpectedCurrent, d.ActualWithdrawn, d.ExpectedWithdrawn)
+
+		if !fix {
+			continue
+		}
+
+		fixed, err := rs.repo.FixDiscrepancy(ctx, d.UserID)
+		if errors.Is(err, repository.ErrHeldExceedsBalance) {
+			logger.Log.Sugar().Warnf("balance of user %d needs a manual fix: %v", d.UserID, err)
+			report.Discrepancies[i].Reason = err.Error()
+			continue
+		}
+		if err != nil {
+			logger.Log.Sugar().Errorf("failed to fix balance of user %d: %v", d.UserID, err)
+			continue
+		}
+		report.Discrepancies[i].Fixed = fixed
+	}
+
+	return report, nil
+}
+
+// Run reconciles balances every interval until ctx is done.
+func (rs *ReconciliationService) Run(ctx context.Context, interval time.Duration, fix bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := rs.Reconcile(ctx, fix)
+			if err != nil {
+				logger.Log.Sugar().Errorf("balance reconciliation failed: %v", err)
+				continue
+			}
+			logger.Log.Sugar().Infof("balance reconciliation: %d users checked, %d discrepancies, %d unresolved",
+				report.UsersChecked, len(report.Discrepancies), report.Unresolved())
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+)
+
+type Referrals interface {
+	ListReferrals(ctx context.Context, userID int) (models.Referrals, error)
+}
+
+// ReferralsService shows users their invites, referrals are recorded on
+// registration and rewarded by the order processing.
+type ReferralsService struct {
+	repo repository.ReferralsRepository
+}
+
+func NewReferralsService(repo repository.ReferralsRepository) *ReferralsService {
+	return &ReferralsService{repo: repo}
+}
+
+func (rs *ReferralsService) ListReferrals(ctx context.Context, userID int) (models.Referrals, error) {
+	return rs.repo.ListReferrals(ctx, userID)
+}
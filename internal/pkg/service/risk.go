@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/risk"
+)
+
+var ErrRiskBlocked = errors.New("request was blocked by risk checks")
+
+// RiskAssessor is consulted before an order upload, a withdrawal, a hold
+// capture or a transfer is accepted.
+type RiskAssessor interface {
+	Assess(ctx context.Context, event models.RiskEvent) error
+}
+
+// Risk is the admin side of the risk checks.
+type Risk interface {
+	ListReviews(ctx context.Context, status string) ([]models.RiskReview, error)
+	ResolveReview(ctx context.Context, id int64, resolution models.ReviewResolution) (models.RiskReview, error)
+}
+
+type RiskService struct {
+	repo   repository.RiskRepository
+	scorer *risk.Scorer
+	cfg    risk.Config
+}
+
+func NewRiskService(repo repository.RiskRepository, cfg risk.Config, extra ...risk.Signal) *RiskService {
+	return &RiskService{repo: repo, scorer: risk.NewScorer(cfg, extra...), cfg: cfg}
+}
+
+// Assess scores the event, flagged and blocked events are queued for review
+// and blocked ones are rejected with ErrRiskBlocked. Failures of the risk
+// checks themselves let the event through, they must not stop the service.
+func (rs *RiskService) Assess(ctx context.Context, event models.RiskEvent) error {
+	stats, err := rs.repo.RiskStats(ctx, event.UserID, rs.cfg.VelocityWindow())
+	if err != nil {
+		logger.Log.Sugar().Errorf("risk assessment of user %d skipped: %v", event.UserID, err)
+		return nil
+	}
+
+	decision := rs.scorer.Score(event, stats)
+	if decision.Action == models.RiskActionAllow {
+		return nil
+	}
+
+	if _, err := rs.repo.CreateReview(ctx, event, decision); err != nil {
+		logger.Log.Sugar().Errorf("failed to queue risk review for user %d: %v", event.UserID, err)
+	}
+
+	if decision.Action == models.RiskActionBlock {
+		logger.Log.Sugar().Warnf("%s of user %d blocked, score %d: %v", event.Kind, event.UserID, decision.Score, decision.Reasons)
+		return ErrRiskBlocked
+	}
+	return nil
+}
+
+func (rs *RiskService) ListReviews(ctx context.Context, status string) ([]models.RiskReview, error) {
+	return rs.repo.ListReviews(ctx, status)
+}
+
+// ResolveReview only records the verdict, see models.ReviewResolution.
+func (rs *RiskService) ResolveReview(ctx context.Context, id int64, resolution models.ReviewResolution) (models.RiskReview, error) {
+	if err := resolution.Validate(); err != nil {
+		return models.RiskReview{}, err
+	}
+	return rs.repo.ResolveReview(ctx, id, resolution)
+}
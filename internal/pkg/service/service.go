@@ -4,14 +4,32 @@ type Service struct {
 	Authorization   Authorization
 	Order           Order
 	Balance         Balance
+	Holds           Holds
+	Transfers       Transfers
+	Referrals       Referrals
+	Statements      Statements
+	Risk            Risk
+	Tiers           Tiers
+	Campaigns       Campaigns
 	OrderProcessing OrderProcessing
+	AccrualRules    AccrualRules
+	Idempotency     Idempotency
 }
 
 type Dependencies struct {
 	Authorization   Authorization
 	Order           Order
 	Balance         Balance
+	Holds           Holds
+	Transfers       Transfers
+	Referrals       Referrals
+	Statements      Statements
+	Risk            Risk
+	Tiers           Tiers
+	Campaigns       Campaigns
 	OrderProcessing OrderProcessing
+	AccrualRules    AccrualRules
+	Idempotency     Idempotency
 }
 
 func NewService(deps Dependencies) *Service {
@@ -19,6 +37,15 @@ func NewService(deps Dependencies) *Service {
 		Authorization:   deps.Authorization,
 		Order:           deps.Order,
 		Balance:         deps.Balance,
+		Holds:           deps.Holds,
+		Transfers:       deps.Transfers,
+		Referrals:       deps.Referrals,
+		Statements:      deps.Statements,
+		Risk:            deps.Risk,
+		Tiers:           deps.Tiers,
+		Campaigns:       deps.Campaigns,
 		OrderProcessing: deps.OrderProcessing,
+		AccrualRules:    deps.AccrualRules,
+		Idempotency:     deps.Idempotency,
 	}
 }
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+)
+
+type Statements interface {
+	MonthlyStatement(ctx context.Context, userID int, month time.Time) (models.MonthlyStatement, error)
+}
+
+var ErrStatementMonthInFuture = errors.New("statement month has not started yet")
+
+// StatementsService generates monthly statements. Statements of closed months
+// are cached, the current month is generated on every request.
+type StatementsService struct {
+	repo repository.StatementsRepository
+}
+
+func NewStatementsService(repo repository.StatementsRepository) *StatementsService {
+	return &StatementsService{repo: repo}
+}
+
+func (ss *StatementsService) MonthlyStatement(ctx context.Context, userID int, month time.Time) (models.MonthlyStatement, error) {
+	now := time.Now().UTC()
+	if month.After(now) {
+		return models.MonthlyStatement{}, ErrStatementMonthInFuture
+	}
+
+	closed := !month.AddDate(0, 1, 0).After(now)
+	if closed {
+		statement, ok, err := ss.repo.GetCachedStatement(ctx, userID, month)
+		if err != nil {
+			return models.MonthlyStatement{}, err
+		}
+		if ok {
+			return statement, nil
+		}
+	}
+
+	statement, err := ss.repo.BuildStatement(ctx, userID, month)
+	if err != nil {
+		return models.MonthlyStatement{}, err
+	}
+
+	if closed {
+		// a failed save only costs regenerating the statement next time
+		if err := ss.repo.SaveStatement(ctx, userID, month, statement); err != nil {
+			logger.Log.Sugar().Errorf("failed to cache statement %s of user %d: %v", statement.Month, userID, err)
+		}
+	}
+	return statement, nil
+}
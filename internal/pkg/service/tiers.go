@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/logger"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+)
+
+type Tiers interface {
+	UserTier(ctx context.Context, userID int) (models.TierStatus, error)
+}
+
+// LoadTiers reads tier definitions from a JSON file like
+// [{"name": "Silver", "threshold": 1000, "multiplier": 1.1}, ...].
+func LoadTiers(path string) ([]models.Tier, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tiers config: %w", err)
+	}
+
+	var tiers []models.Tier
+	if err := json.Unmarshal(data, &tiers); err != nil {
+		return nil, fmt.Errorf("failed to decode tiers config: %w", err)
+	}
+
+	if err := ValidateTiers(tiers); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Threshold < tiers[j].Threshold })
+	return tiers, nil
+}
+
+func ValidateTiers(tiers []models.Tier) error {
+	names := make(map[string]struct{}, len(tiers))
+	thresholds := make(map[models.Money]struct{}, len(tiers))
+	for _, tier := range tiers {
+		if tier.Name == "" {
+			return fmt.Errorf("tier without name")
+		}
+		if _, ok := names[tier.Name]; ok {
+			return fmt.Errorf("duplicate tier: %s", tier.Name)
+		}
+		names[tier.Name] = struct{}{}
+
+		if tier.Threshold <= 0 {
+			return fmt.Errorf("tier %s must have a positive threshold", tier.Name)
+		}
+		if _, ok := thresholds[tier.Threshold]; ok {
+			return fmt.Errorf("tier %s has the same threshold as another tier", tier.Name)
+		}
+		thresholds[tier.Threshold] = struct{}{}
+
+		if tier.Multiplier <= 0 {
+			return fmt.Errorf("tier %s must have a positive multiplier", tier.Name)
+		}
+		if !models.MultiplierFits(tier.Multiplier) {
+			return fmt.Errorf("tier %s must have a multiplier below %d with at most 4 decimals",
+				tier.Name, models.MaxMultiplier)
+		}
+	}
+	return nil
+}
+
+type TiersService struct {
+	repo  repository.TiersRepository
+	tiers []models.Tier
+}
+
+// NewTiersService expects tiers sorted by threshold, as returned by LoadTiers.
+func NewTiersService(repo repository.TiersRepository, tiers []models.Tier) *TiersService {
+	return &TiersService{repo: repo, tiers: tiers}
+}
+
+// UserTier reports the tier assigned by the last recomputation and what the
+// user still has to accrue to reach the next one.
+func (ts *TiersService) UserTier(ctx context.Context, userID int) (models.TierStatus, error) {
+	tier, err := ts.repo.GetUserTier(ctx, userID)
+	if err != nil {
+		return models.TierStatus{}, err
+	}
+
+	status := models.TierStatus{
+		Name:           tier.Name,
+		Multiplier:     tier.Multiplier,
+		RollingAccrual: tier.RollingAccrual,
+	}
+	for _, next := range ts.tiers {
+		if next.Threshold > tier.RollingAccrual {
+			status.NextTier = next.Name
+			status.ToNextTier = next.Threshold - tier.RollingAccrual
+			break
+		}
+	}
+	return status, nil
+}
+
+func (ts *TiersService) Recompute(ctx context.Context) (int64, error) {
+	return ts.repo.RecomputeTiers(ctx, ts.tiers)
+}
+
+// Run recomputes tiers right away and then every interval until ctx is done.
+func (ts *TiersService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		users, err := ts.Recompute(ctx)
+		if err != nil {
+			logger.Log.Sugar().Errorf("tier recomputation failed: %v", err)
+		} else {
+			logger.Log.Sugar().Infof("tiers recomputed for %d users", users)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0x24CaptainParrot/gophermart-service/internal/models"
+	"github.com/0x24CaptainParrot/gophermart-service/internal/pkg/repository"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type Transfers interface {
+	Transfer(ctx context.Context, senderID int, req models.TransferRequest) (models.Transfer, error)
+	ListTransfers(ctx context.Context, userID int, page models.Page) ([]models.Transfer, error)
+}
+
+type TransfersService struct {
+	repo             repository.TransfersRepository
+	limits           models.TransferLimits
+	withdrawalLimits models.WithdrawalLimits
+	risk             RiskAssessor
+}
+
+// NewTransfersService takes a nil risk when risk checks are disabled.
+func NewTransfersService(repo repository.TransfersRepository, limits models.TransferLimits,
+	withdrawalLimits models.WithdrawalLimits, risk RiskAssessor) *TransfersService {
+	return &TransfersService{repo: repo, limits: limits, withdrawalLimits: withdrawalLimits, risk: risk}
+}
+
+const transferAttempts = 3
+
+// Transfer retries transfers that lost a serialization conflict, e.g. two
+// transfers of the same sender racing for the daily limit.
+func (ts *TransfersService) Transfer(ctx context.Context, senderID int, req models.TransferRequest) (models.Transfer, error) {
+	if err := ts.withdrawalLimits.CheckSum(req.Sum); err != nil {
+		return models.Transfer{}, err
+	}
+
+	if ts.risk != nil {
+		event := models.RiskEvent{Kind: models.RiskEventTransfer, UserID: senderID, Sum: req.Sum}
+		if err := ts.risk.Assess(ctx, event); err != nil {
+			return models.Transfer{}, err
+		}
+	}
+
+	var lastErr error
+	for i := 0; i < transferAttempts; i++ {
+		transfer, err := ts.repo.CreateTransfer(ctx, senderID, req, ts.limits, ts.withdrawalLimits)
+		if err == nil {
+			return transfer, nil
+		}
+
+		var pgError *pgconn.PgError
+		if !errors.As(err, &pgError) || !pgerrcode.IsTransactionRollback(pgError.Code) {
+			return models.Transfer{}, err
+		}
+		lastErr = err
+
+		select {
+		case <-ctx.Done():
+			return models.Transfer{}, ctx.Err()
+		case <-time.After(time.Duration(i+1) * 50 * time.Millisecond):
+		}
+	}
+	return models.Transfer{}, lastErr
+}
+
+func (ts *TransfersService) ListTransfers(ctx context.Context, userID int, page models.Page) ([]models.Transfer, error) {
+	return ts.repo.ListTransfers(ctx, userID, page)
+}